@@ -43,7 +43,7 @@ func TestAdd(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			db, _ := testutils.InMemDb(t)
-			cmd := NewRootCmd(db)
+			cmd := NewRootCmd(db, &testutils.FakeRunner{})
 			actual := testutils.Execute(cmd, tt.args...)
 			assert.Equal(t, tt.expected, actual)
 		})
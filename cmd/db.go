@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/vague2k/rummage/pkg/commands"
+	"github.com/vague2k/rummage/pkg/database"
+)
+
+func newDbCmd(db *database.Queries, ctx context.Context) *cobra.Command {
+	dbCmd := &cobra.Command{
+		Use:   "db",
+		Short: "Manage the rummage database itself",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := cmd.Help(); err != nil {
+				cmd.PrintErr(err)
+			}
+		},
+	}
+
+	dbCmd.AddCommand(newMigrateCmd(db, ctx))
+
+	return dbCmd
+}
+
+func newMigrateCmd(db *database.Queries, ctx context.Context) *cobra.Command {
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply any pending schema migrations to the database, this already happens every time rummage starts",
+		Run: func(cmd *cobra.Command, args []string) {
+			commands.Migrate(cmd, args, db, ctx)
+		},
+	}
+
+	migrateCmd.Flags().BoolP("status", "s", false, "Show which migrations have been applied and which are pending instead of migrating")
+
+	return migrateCmd
+}
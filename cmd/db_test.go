@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vague2k/rummage/testutils"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Run("Shows the status of every migration", func(t *testing.T) {
+		db, _ := testutils.InMemDb(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "db", "migrate", "--status")
+
+		expected := "applied : 0001_create_items\n" +
+			"applied : 0002_create_access_log\n" +
+			"applied : 0003_create_meta\n" +
+			"applied : 0004_create_versions\n" +
+			"applied : 0005_add_pinned\n" +
+			"database is at version 5 with 0 pending migrations\n"
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("Does nothing if the database is up to date", func(t *testing.T) {
+		db, _ := testutils.InMemDb(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "db", "migrate")
+
+		assert.Equal(t, "database is already up to date at version 5\n", actual)
+	})
+}
@@ -6,20 +6,29 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/vague2k/rummage/pkg/commands"
 	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/toolchain"
 )
 
-func newGetCmd(db *database.Queries, ctx context.Context) *cobra.Command {
+func newGetCmd(db *database.Queries, ctx context.Context, runner toolchain.GoRunner) *cobra.Command {
 	getCmd := &cobra.Command{
-		Use:   "get",
+		Use:   "get [packages...] [-- go get flags...]",
 		Short: "Get a go package from the database using a substring, or get a package how you normally would",
 		Run: func(cmd *cobra.Command, args []string) {
-			commands.Get(cmd, args, db, ctx)
+			commands.Get(cmd, args, db, ctx, runner)
 		},
 	}
 
-	getCmd.Flags().BoolP("update", "u", false, "same as '-u', see 'go help get'")
+	getCmd.Flags().StringP("update", "u", "", "same as '-u' or '-u=patch', see 'go help get'")
+	getCmd.Flags().Lookup("update").NoOptDefVal = "true"
 	getCmd.Flags().BoolP("dependencies", "t", false, "same as '-t', see 'go help get'")
 	getCmd.Flags().BoolP("debug", "x", false, "same as '-x', see 'go help get'")
+	getCmd.Flags().BoolP("interactive", "i", false, "Pick which matching entry to get from a list, only when stdin is a terminal")
+	getCmd.Flags().Float64("pick-threshold", 0.1, "Pick from a list when the runner up's rank is within this fraction of the best match's rank, 0 to never pick automatically")
+	getCmd.Flags().BoolP("dry-run", "n", false, "Print what each arg resolves to and the 'go get' command that would run, without running it")
+	getCmd.Flags().Bool("pin", false, "Pin the entry to the version that was fetched, so later gets without a version reuse it (see 'rummage pin')")
+	getCmd.Flags().StringSlice("workspace-module", nil, "Run 'go get' in each of these modules of the go.work workspace (e.g. 'api,worker')")
+	getCmd.Flags().Bool("all-modules", false, "Run 'go get' in every module of the go.work workspace")
+	getCmd.Flags().BoolP("multi", "m", false, "Combine all args into one query for a single package, where each arg has to match after the previous one (e.g. 'charm bubbles')")
 
 	return getCmd
 }
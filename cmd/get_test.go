@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/toolchain"
 	"github.com/vague2k/rummage/testutils"
 )
 
@@ -16,35 +21,45 @@ import (
 // these tests do not need such preciseness
 // See testutils.First8()
 
+// Responds the way "go get" does when the file passed to "-modfile" does not exist
+func missingModfile(dir string, args ...string) (string, error) {
+	for _, arg := range args {
+		if arg == "-modfile=missing.mod" {
+			return "go: open missing.mod: no such file or directory\n", errors.New("exit status 1")
+		}
+	}
+	return "", nil
+}
+
 func TestGetHighestScore(t *testing.T) {
 	tests := []struct {
-		name string
-		args []string
+		name    string
+		args    []string
+		command string
 	}{
 		{
-			name: "Get item with highest score",
-			args: []string{"get", "bubble"},
+			name:    "Get item with highest score",
+			args:    []string{"get", "bubble"},
+			command: "get github.com/charmbracelet/bubbletea",
 		},
 		{
-			name: "Get item with highest score with -u",
-			args: []string{"get", "-u", "bubble"},
+			name:    "Get item with highest score with -u",
+			args:    []string{"get", "-u", "bubble"},
+			command: "get -u github.com/charmbracelet/bubbletea",
 		},
 		{
-			name: "Get item with highest score with -t",
-			args: []string{"get", "-t", "bubble"},
+			name:    "Get item with highest score with -t",
+			args:    []string{"get", "-t", "bubble"},
+			command: "get -t github.com/charmbracelet/bubbletea",
 		},
 		{
-			name: "Get item with highest score with -x",
-			args: []string{"get", "-x", "bubble"},
+			name:    "Get item with highest score with -x",
+			args:    []string{"get", "-x", "bubble"},
+			command: "get -x github.com/charmbracelet/bubbletea",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			testutils.GoModTidy(t)
-			t.Cleanup(func() {
-				testutils.GoModTidy(t)
-			})
-
 			pkgs := []string{"github.com/charmbracelet/bubbletea", "github.com/charmbracelet/bubbles"}
 			db, ctx := testutils.InMemDb(t)
 
@@ -60,22 +75,18 @@ func TestGetHighestScore(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			cmd := NewRootCmd(db)
+			runner := &testutils.FakeRunner{}
+			cmd := NewRootCmd(db, runner)
 			actual := testutils.Execute(cmd, tt.args...)
 
 			assert.Contains(t, actual, "go: added github.com/charmbracelet/bubbletea")
 			assert.NotContains(t, actual, "go: added github.com/charmbracelet/bubbles")
-			testutils.GoModTidy(t)
+			assert.Equal(t, []string{tt.command}, runner.Commands())
 		})
 	}
 }
 
 func TestGetHighestScoreErrors(t *testing.T) {
-	testutils.GoModTidy(t)
-	t.Cleanup(func() {
-		testutils.GoModTidy(t)
-	})
-
 	tests := []struct {
 		name     string
 		args     []string
@@ -106,49 +117,193 @@ func TestGetHighestScoreErrors(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			db, _ := testutils.InMemDb(t)
 
-			cmd := NewRootCmd(db)
+			runner := &testutils.FakeRunner{}
+			cmd := NewRootCmd(db, runner)
 			actual := testutils.Execute(cmd, tt.args...)
 
 			assert.Equal(t, "no match found with the given arguement mux\n", actual)
-			testutils.GoModTidy(t)
+			assert.Empty(t, runner.Calls)
 		})
 	}
 }
 
-func TestGetMultiHighestScore(t *testing.T) {
-	testutils.GoModTidy(t)
-	t.Cleanup(func() {
-		testutils.GoModTidy(t)
-	})
+func TestGetVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "Can get item at a version",
+			args:     []string{"get", "github.com/gorilla/mux@v1.8.0"},
+			expected: "go: added github.com/gorilla/mux v1.8.0",
+		},
+		{
+			name:     "Can get highest score item at a version",
+			args:     []string{"get", "mux@v1.8.0"},
+			expected: "go: added github.com/gorilla/mux v1.8.0",
+		},
+		{
+			name:     "Can get highest score item at a query",
+			args:     []string{"get", "MUX@latest"},
+			expected: "go: added github.com/gorilla/mux v1.0.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, ctx := testutils.InMemDb(t)
+			_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 1.0})
+			assert.NoError(t, err)
+
+			runner := &testutils.FakeRunner{}
+			cmd := NewRootCmd(db, runner)
+			actual := testutils.Execute(cmd, tc.args...)
+
+			assert.Contains(t, actual, tc.expected)
+			_, err = db.SelectItem(ctx, "github.com/gorilla/mux@v1.8.0")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGetPinned(t *testing.T) {
+	db, ctx := testutils.InMemDb(t)
+	runner := &testutils.FakeRunner{}
+
+	actual := testutils.Execute(NewRootCmd(db, runner), "get", "--pin", "github.com/gorilla/mux@v1.8.0")
+	assert.Contains(t, actual, "pinned github.com/gorilla/mux to v1.8.0\n")
+	item, err := db.SelectItem(ctx, "github.com/gorilla/mux")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.8.0", item.Pinned)
+
+	actual = testutils.Execute(NewRootCmd(db, runner), "get", "mux")
+	assert.Contains(t, actual, "using pinned version v1.8.0 of github.com/gorilla/mux\n")
+	assert.Contains(t, actual, "go: added github.com/gorilla/mux v1.8.0")
+	assert.Equal(t, []string{"get github.com/gorilla/mux@v1.8.0", "get github.com/gorilla/mux@v1.8.0"}, runner.Commands())
+}
 
+func TestGetPinRequired(t *testing.T) {
 	tests := []struct {
-		name string
-		args []string
+		name     string
+		list     string
+		expected string
+		pinned   string
 	}{
 		{
-			name: "Get multiple items with highest score",
-			args: []string{"get", "bubble", "mux"},
+			name:     "Pins the version go.mod already requires",
+			list:     "example.com/app\ngithub.com/gorilla/mux v1.8.0\n",
+			expected: "pinned github.com/gorilla/mux to v1.8.0\n",
+			pinned:   "v1.8.0",
 		},
 		{
-			name: "Get multiple items with highest score with -u",
-			args: []string{"get", "-u", "bubble", "mux"},
+			name:     "Still records the access when no version is found",
+			list:     "example.com/app\n",
+			expected: "could not tell which version of github.com/gorilla/mux was fetched, nothing was pinned\n",
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, ctx := testutils.InMemDb(t)
+			_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 1.0, Lastaccessed: time.Now().Unix()})
+			assert.NoError(t, err)
+
+			// "go get" does not report anything for a module that's already required at the version it resolves to
+			runner := &testutils.FakeRunner{Respond: func(dir string, args ...string) (string, error) {
+				if args[0] == "list" {
+					return tt.list, nil
+				}
+				return "", nil
+			}}
+			actual := testutils.Execute(NewRootCmd(db, runner), "get", "--pin", "mux")
+
+			assert.Contains(t, actual, tt.expected)
+			assert.Equal(t, []string{"get github.com/gorilla/mux", "list -m all"}, runner.Commands())
+			item, err := db.SelectItem(ctx, "github.com/gorilla/mux")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.pinned, item.Pinned)
+			assert.Equal(t, 5.0, item.Score)
+			logs, err := db.SelectAccessLog(ctx, "github.com/gorilla/mux")
+			assert.NoError(t, err)
+			assert.Len(t, logs, 1)
+		})
+	}
+}
+
+func TestGetFlagsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
 		{
-			name: "Get multiple items with highest score with -t",
-			args: []string{"get", "-t", "bubble", "mux"},
+			name:     "Errors with an unknown update mode",
+			args:     []string{"get", "-u=major", "mux"},
+			expected: "unknown update mode major, -u can only be used as -u or -u=patch\n",
 		},
 		{
-			name: "Get multiple items with highest score with -x",
-			args: []string{"get", "-x", "bubble", "mux"},
+			name:     "Passes flags after -- through to go get",
+			args:     []string{"get", "github.com/gorilla/mux", "--", "-modfile=missing.mod"},
+			expected: "go: open missing.mod: no such file or directory\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, ctx := testutils.InMemDb(t)
+
+			runner := &testutils.FakeRunner{Respond: missingModfile}
+			cmd := NewRootCmd(db, runner)
+			actual := testutils.Execute(cmd, tt.args...)
+
+			assert.Contains(t, actual, tt.expected)
+			_, err := db.SelectItem(ctx, "github.com/gorilla/mux")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGetVersionErrors(t *testing.T) {
+	db, _ := testutils.InMemDb(t)
+
+	runner := &testutils.FakeRunner{}
+	cmd := NewRootCmd(db, runner)
+	actual := testutils.Execute(cmd, "get", "mux@v1.8.0")
+
+	assert.Equal(t, "no match found with the given arguement mux\n", actual)
+}
+
+func TestGetMultiHighestScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		command string
+	}{
+		{
+			name:    "Get multiple items with highest score",
+			args:    []string{"get", "bubble", "mux"},
+			command: "get github.com/charmbracelet/bubbletea github.com/gorilla/mux",
+		},
+		{
+			name:    "Get multiple items with highest score with -u",
+			args:    []string{"get", "-u", "bubble", "mux"},
+			command: "get -u github.com/charmbracelet/bubbletea github.com/gorilla/mux",
+		},
+		{
+			name:    "Get multiple items with highest score with -t",
+			args:    []string{"get", "-t", "bubble", "mux"},
+			command: "get -t github.com/charmbracelet/bubbletea github.com/gorilla/mux",
+		},
+		{
+			name:    "Get multiple items with highest score with -x",
+			args:    []string{"get", "-x", "bubble", "mux"},
+			command: "get -x github.com/charmbracelet/bubbletea github.com/gorilla/mux",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			testutils.GoModTidy(t)
-			t.Cleanup(func() {
-				testutils.GoModTidy(t)
-			})
 			db, ctx := testutils.InMemDb(t)
 			pkgs := []string{"github.com/charmbracelet/bubbletea", "github.com/charmbracelet/bubbles", "github.com/gorilla/mux"}
 			for _, entry := range pkgs {
@@ -163,23 +318,19 @@ func TestGetMultiHighestScore(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			cmd := NewRootCmd(db)
+			runner := &testutils.FakeRunner{}
+			cmd := NewRootCmd(db, runner)
 			actual := testutils.Execute(cmd, tt.args...)
 
 			assert.Contains(t, actual, "go: added github.com/charmbracelet/bubbletea")
 			assert.Contains(t, actual, "go: added github.com/gorilla/mux")
 			assert.NotContains(t, actual, "go: added github.com/charmbracelet/bubbles")
-			testutils.GoModTidy(t)
+			assert.Equal(t, []string{tt.command}, runner.Commands())
 		})
 	}
 }
 
 func TestGetMultiHighestScoreErrors(t *testing.T) {
-	testutils.GoModTidy(t)
-	t.Cleanup(func() {
-		testutils.GoModTidy(t)
-	})
-
 	tests := []struct {
 		name string
 		args []string
@@ -205,47 +356,65 @@ func TestGetMultiHighestScoreErrors(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			db, _ := testutils.InMemDb(t)
 
-			cmd := NewRootCmd(db)
+			runner := &testutils.FakeRunner{}
+			cmd := NewRootCmd(db, runner)
 			actual := testutils.Execute(cmd, tt.args...)
 
 			assert.Equal(t, "no match found with the given arguement bubbles\nno match found with the given arguement mux\n", actual)
-			testutils.GoModTidy(t)
+			assert.Empty(t, runner.Calls)
 		})
 	}
 }
 
 func TestGet(t *testing.T) {
 	tests := []struct {
-		name string
-		args []string
+		name    string
+		args    []string
+		command string
 	}{
 		{
-			name: "Can get item",
-			args: []string{"get", "github.com/gorilla/mux"},
+			name:    "Can get item",
+			args:    []string{"get", "github.com/gorilla/mux"},
+			command: "get github.com/gorilla/mux",
+		},
+		{
+			name:    "Can get item with -u flag",
+			args:    []string{"get", "-u", "github.com/gorilla/mux"},
+			command: "get -u github.com/gorilla/mux",
+		},
+		{
+			name:    "Can get item with -t flag",
+			args:    []string{"get", "-t", "github.com/gorilla/mux"},
+			command: "get -t github.com/gorilla/mux",
+		},
+		{
+			name:    "Can get item with -x flag",
+			args:    []string{"get", "-x", "github.com/gorilla/mux"},
+			command: "get -x github.com/gorilla/mux",
 		},
 		{
-			name: "Can get item with -u flag",
-			args: []string{"get", "-u", "github.com/gorilla/mux"},
+			name:    "Can get item with -u=patch flag",
+			args:    []string{"get", "-u=patch", "github.com/gorilla/mux"},
+			command: "get -u=patch github.com/gorilla/mux",
 		},
 		{
-			name: "Can get item with -t flag",
-			args: []string{"get", "-t", "github.com/gorilla/mux"},
+			name:    "Can get item with combined flags",
+			args:    []string{"get", "-u", "-t", "-x", "github.com/gorilla/mux"},
+			command: "get -u -t -x github.com/gorilla/mux",
 		},
 		{
-			name: "Can get item with -x flag",
-			args: []string{"get", "-x", "github.com/gorilla/mux"},
+			name:    "Can get item with passed through flags",
+			args:    []string{"get", "github.com/gorilla/mux", "--", "-v"},
+			command: "get -v github.com/gorilla/mux",
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			testutils.GoModTidy(t)
-			t.Cleanup(func() {
-				testutils.GoModTidy(t)
-			})
 			db, ctx := testutils.InMemDb(t)
 
-			cmd := NewRootCmd(db)
+			runner := &testutils.FakeRunner{}
+			cmd := NewRootCmd(db, runner)
 			actual := testutils.Execute(cmd, tc.args...)
 
 			item, err := db.SelectItem(ctx, "github.com/gorilla/mux")
@@ -254,7 +423,13 @@ func TestGet(t *testing.T) {
 			assert.Contains(t, actual, "go: added github.com/gorilla/mux")
 			assert.Equal(t, 5.0, item.Score)
 			assert.Equal(t, testutils.First8(time.Now().Unix()), testutils.First8(item.Lastaccessed))
-			testutils.GoModTidy(t)
+
+			logs, err := db.SelectAccessLog(ctx, "github.com/gorilla/mux")
+			assert.NoError(t, err)
+			assert.Len(t, logs, 1)
+			assert.Equal(t, "get", logs[0].Command)
+			assert.Equal(t, item.Lastaccessed, logs[0].Timestamp)
+			assert.Equal(t, []string{tc.command}, runner.Commands())
 		})
 	}
 }
@@ -284,13 +459,10 @@ func TestMultipleGet(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			testutils.GoModTidy(t)
-			t.Cleanup(func() {
-				testutils.GoModTidy(t)
-			})
 			db, ctx := testutils.InMemDb(t)
 
-			cmd := NewRootCmd(db)
+			runner := &testutils.FakeRunner{}
+			cmd := NewRootCmd(db, runner)
 			actual := testutils.Execute(cmd, tc.args...)
 
 			pkgs := []string{
@@ -306,7 +478,433 @@ func TestMultipleGet(t *testing.T) {
 				assert.Equal(t, testutils.First8(time.Now().Unix()), testutils.First8(item.Lastaccessed))
 			}
 
-			testutils.GoModTidy(t)
 		})
 	}
 }
+
+func TestMultipleGetErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "Nothing is fetched if an item does not exist",
+			args:     []string{"get", "mux", "fiber"},
+			expected: "no match found with the given arguement fiber\n",
+		},
+		{
+			name:     "Nothing is fetched if two items ask for different versions of the same entry",
+			args:     []string{"get", "mux@v1.8.0", "github.com/gorilla/mux@v1.7.0"},
+			expected: "mux@v1.8.0 and github.com/gorilla/mux@v1.7.0 both resolve to github.com/gorilla/mux at different versions\n",
+		},
+		{
+			name:     "Nothing is fetched if go get fails for any item",
+			args:     []string{"get", "mux", "github.com/gofiber/fiber/v2", "--", "-modfile=missing.mod"},
+			expected: "go: open missing.mod: no such file or directory\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, ctx := testutils.InMemDb(t)
+			_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 1.0})
+			assert.NoError(t, err)
+
+			runner := &testutils.FakeRunner{Respond: missingModfile}
+			cmd := NewRootCmd(db, runner)
+			actual := testutils.Execute(cmd, tt.args...)
+
+			assert.Equal(t, tt.expected, actual)
+			item, err := db.SelectItem(ctx, "github.com/gorilla/mux")
+			assert.NoError(t, err)
+			assert.Equal(t, 1.0, item.Score)
+			_, err = db.SelectItem(ctx, "github.com/gofiber/fiber/v2")
+			assert.Error(t, err)
+			logs, err := db.SelectAllAccessLogs(ctx)
+			assert.NoError(t, err)
+			assert.Empty(t, logs)
+		})
+	}
+}
+
+func TestGetDryRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		pinned   string
+		expected string
+	}{
+		{
+			name: "Prints what each arg resolves to",
+			args: []string{"get", "--dry-run", "-u", "mux", "github.com/gofiber/fiber/v2@v2.52.0", "github.com/gorilla/mux"},
+			expected: "mux -> github.com/gorilla/mux (matched with rank 6.0000, score 5.0000)\n" +
+				"github.com/gofiber/fiber/v2 -> github.com/gofiber/fiber/v2 (not in the database yet)\n" +
+				"go get -u github.com/gorilla/mux github.com/gofiber/fiber/v2@v2.52.0\n",
+		},
+		{
+			name:     "Prints exact entries with their score",
+			args:     []string{"get", "-n", "github.com/gorilla/mux", "--", "-modfile=my tools.mod"},
+			expected: "github.com/gorilla/mux -> github.com/gorilla/mux (score 5.0000)\n" + `go get "-modfile=my tools.mod" github.com/gorilla/mux` + "\n",
+		},
+		{
+			name:     "Prints the go get command without packages",
+			args:     []string{"get", "-n", "-u=patch"},
+			expected: "go get -u=patch\n",
+		},
+		{
+			name:     "Prints the pinned version an entry resolves to",
+			args:     []string{"get", "-n", "github.com/gorilla/mux"},
+			pinned:   "v1.8.0",
+			expected: "github.com/gorilla/mux -> github.com/gorilla/mux (score 5.0000, pinned to v1.8.0)\ngo get github.com/gorilla/mux@v1.8.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, ctx := testutils.InMemDb(t)
+			_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 5.0})
+			assert.NoError(t, err)
+			_, err = db.PinItem(ctx, database.PinItemParams{Pinned: tt.pinned, Entry: "github.com/gorilla/mux"})
+			assert.NoError(t, err)
+
+			runner := &testutils.FakeRunner{}
+			cmd := NewRootCmd(db, runner)
+			actual := testutils.Execute(cmd, tt.args...)
+
+			assert.Equal(t, tt.expected, actual)
+			assert.Empty(t, runner.Calls)
+			item, err := db.SelectItem(ctx, "github.com/gorilla/mux")
+			assert.NoError(t, err)
+			assert.Equal(t, 5.0, item.Score)
+			_, err = db.SelectItem(ctx, "github.com/gofiber/fiber/v2")
+			assert.Error(t, err)
+			logs, err := db.SelectAllAccessLogs(ctx)
+			assert.NoError(t, err)
+			assert.Empty(t, logs)
+		})
+	}
+}
+
+func TestGetEndToEnd(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+		requires []string
+	}{
+		{
+			name:     "Adds the newest version of the highest score item",
+			args:     []string{"get", "mux"},
+			expected: []string{"go: added github.com/gorilla/mux v1.8.1"},
+			requires: []string{"github.com/gorilla/mux v1.8.1"},
+		},
+		{
+			name:     "Adds the version asked for",
+			args:     []string{"get", "mux@v1.8.0"},
+			expected: []string{"go: added github.com/gorilla/mux v1.8.0"},
+			requires: []string{"github.com/gorilla/mux v1.8.0"},
+		},
+		{
+			name: "Adds every item with a single go get",
+			args: []string{"get", "mux", "bubble"},
+			expected: []string{
+				"go: added github.com/gorilla/mux v1.8.1",
+				"go: added github.com/charmbracelet/bubbletea v1.0.0",
+			},
+			requires: []string{"github.com/gorilla/mux v1.8.1", "github.com/charmbracelet/bubbletea v1.0.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutils.MockGoProxy(t,
+				testutils.ProxyModule{Path: "github.com/gorilla/mux", Version: "v1.8.0"},
+				testutils.ProxyModule{Path: "github.com/gorilla/mux", Version: "v1.8.1"},
+				testutils.ProxyModule{Path: "github.com/charmbracelet/bubbletea", Version: "v1.0.0"},
+			)
+			dir := testutils.MockGoModule(t, "example.com/app")
+
+			db, ctx := testutils.InMemDb(t)
+			for _, entry := range []string{"github.com/gorilla/mux", "github.com/charmbracelet/bubbletea"} {
+				_, err := db.AddItem(ctx, database.AddItemParams{Entry: entry, Score: 1.0})
+				assert.NoError(t, err)
+			}
+
+			cmd := NewRootCmd(db, toolchain.Exec{})
+			actual := testutils.Execute(cmd, tt.args...)
+
+			for _, e := range tt.expected {
+				assert.Contains(t, actual, e)
+			}
+			goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+			assert.NoError(t, err)
+			for _, r := range tt.requires {
+				assert.Contains(t, string(goMod), r)
+			}
+
+			versions, err := db.SelectVersions(ctx, "github.com/gorilla/mux")
+			assert.NoError(t, err)
+			assert.Len(t, versions, 1)
+			assert.Equal(t, "get", versions[0].Source)
+			logs, err := db.SelectAccessLog(ctx, "github.com/gorilla/mux")
+			assert.NoError(t, err)
+			assert.Len(t, logs, 1)
+			assert.Equal(t, dir, logs[0].Projectdir)
+		})
+	}
+}
+
+func TestGetEndToEndErrors(t *testing.T) {
+	testutils.MockGoProxy(t, testutils.ProxyModule{Path: "github.com/gorilla/mux", Version: "v1.8.1"})
+	dir := testutils.MockGoModule(t, "example.com/app")
+	db, ctx := testutils.InMemDb(t)
+
+	cmd := NewRootCmd(db, toolchain.Exec{})
+	actual := testutils.Execute(cmd, "get", "github.com/gorilla/mux", "github.com/gofiber/fiber/v2")
+
+	assert.Contains(t, actual, "github.com/gofiber/fiber/v2")
+	goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(goMod), "require")
+	_, err = db.SelectItem(ctx, "github.com/gorilla/mux")
+	assert.Error(t, err)
+}
+
+func TestGetDir(t *testing.T) {
+	dir := t.TempDir()
+	db, ctx := testutils.InMemDb(t)
+	_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 1.0})
+	assert.NoError(t, err)
+
+	runner := &testutils.FakeRunner{}
+	actual := testutils.Execute(NewRootCmd(db, runner), "get", "-n", "-C", dir, "mux")
+	assert.Equal(t, "mux -> github.com/gorilla/mux (matched with rank 2.0000, score 1.0000)\n"+fmt.Sprintf("go -C %s get github.com/gorilla/mux\n", dir), actual)
+	assert.Empty(t, runner.Calls)
+
+	actual = testutils.Execute(NewRootCmd(db, runner), "-C", dir, "get", "mux")
+	assert.Equal(t, "go: added github.com/gorilla/mux v1.0.0\n", actual)
+	assert.Equal(t, []testutils.RunnerCall{{Dir: dir, Args: []string{"get", "github.com/gorilla/mux"}}}, runner.Calls)
+	logs, err := db.SelectAccessLog(ctx, "github.com/gorilla/mux")
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, dir, logs[0].Projectdir)
+}
+
+func TestGetDirErrors(t *testing.T) {
+	db, ctx := testutils.InMemDb(t)
+	_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 1.0})
+	assert.NoError(t, err)
+
+	runner := &testutils.FakeRunner{}
+	actual := testutils.Execute(NewRootCmd(db, runner), "get", "-C", filepath.Join(t.TempDir(), "missing"), "mux")
+
+	assert.Contains(t, actual, "missing is not a directory\n")
+	assert.Empty(t, runner.Calls)
+	logs, err := db.SelectAllAccessLogs(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, logs)
+}
+
+func TestGetEndToEndDir(t *testing.T) {
+	testutils.MockGoProxy(t, testutils.ProxyModule{Path: "github.com/gorilla/mux", Version: "v1.8.1"})
+	dir := testutils.MockGoModule(t, "example.com/billing")
+	testutils.Chdir(t, t.TempDir())
+	db, _ := testutils.InMemDb(t)
+
+	cmd := NewRootCmd(db, toolchain.Exec{})
+	actual := testutils.Execute(cmd, "get", "-C", dir, "github.com/gorilla/mux")
+
+	assert.Contains(t, actual, "go: added github.com/gorilla/mux v1.8.1")
+	goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(goMod), "github.com/gorilla/mux v1.8.1")
+}
+
+func TestGetWorkspace(t *testing.T) {
+	dir := testutils.MockProject(t)
+	api, worker := filepath.Join(dir, "api"), filepath.Join(dir, "worker")
+
+	tests := []struct {
+		name     string
+		chdir    string
+		args     []string
+		expected string
+		dirs     []string
+	}{
+		{
+			name:     "Gets in every module",
+			chdir:    dir,
+			args:     []string{"--all-modules", "github.com/gorilla/mux"},
+			expected: "go: added github.com/gorilla/mux v1.0.0\napi: ok\ngo: added github.com/gorilla/mux v1.0.0\nworker: ok\n",
+			dirs:     []string{api, worker},
+		},
+		{
+			name:     "Gets in the chosen modules",
+			chdir:    dir,
+			args:     []string{"--workspace-module", "./worker", "github.com/gorilla/mux"},
+			expected: "go: added github.com/gorilla/mux v1.0.0\nworker: ok\n",
+			dirs:     []string{worker},
+		},
+		{
+			name:     "Finds the go.work in a parent dir",
+			chdir:    api,
+			args:     []string{"--all-modules", "github.com/gorilla/mux"},
+			expected: "go: added github.com/gorilla/mux v1.0.0\napi: ok\ngo: added github.com/gorilla/mux v1.0.0\nworker: ok\n",
+			dirs:     []string{api, worker},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, ctx := testutils.InMemDb(t)
+
+			runner := &testutils.FakeRunner{}
+			actual := testutils.Execute(NewRootCmd(db, runner), append([]string{"get", "-C", tt.chdir}, tt.args...)...)
+
+			assert.Equal(t, tt.expected, actual)
+			logs, err := db.SelectAccessLog(ctx, "github.com/gorilla/mux")
+			assert.NoError(t, err)
+			calls := []testutils.RunnerCall{{Dir: tt.chdir, Args: []string{"env", "GOWORK"}}}
+			for _, d := range tt.dirs {
+				calls = append(calls, testutils.RunnerCall{Dir: d, Args: []string{"get", "github.com/gorilla/mux"}})
+			}
+			assert.Equal(t, calls, runner.Calls)
+			assert.Len(t, logs, 1)
+			assert.Equal(t, strings.Join(tt.dirs, string(os.PathListSeparator)), logs[0].Projectdir)
+			item, err := db.SelectItem(ctx, "github.com/gorilla/mux")
+			assert.NoError(t, err)
+			assert.Equal(t, 5.0, item.Score)
+		})
+	}
+}
+
+func TestGetWorkspaceRebuild(t *testing.T) {
+	dir := testutils.MockProject(t)
+	db, ctx := testutils.InMemDb(t)
+
+	testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "get", "-C", dir, "--all-modules", "github.com/gorilla/mux")
+	live, err := db.SelectItem(ctx, "github.com/gorilla/mux")
+	assert.NoError(t, err)
+
+	actual := testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "rebuild")
+	assert.Equal(t, "rebuilt the score of 1 entries\n", actual)
+
+	rebuilt, err := db.SelectItem(ctx, "github.com/gorilla/mux")
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, live.Score)
+	assert.Equal(t, live.Score, rebuilt.Score)
+	assert.Equal(t, live.Lastaccessed, rebuilt.Lastaccessed)
+}
+
+func TestGetWorkspaceFailure(t *testing.T) {
+	dir := testutils.MockProject(t)
+	db, ctx := testutils.InMemDb(t)
+
+	runner := &testutils.FakeRunner{Respond: func(d string, args ...string) (string, error) {
+		if args[0] == "env" {
+			return filepath.Join(dir, "go.work") + "\n", nil
+		}
+		if filepath.Base(d) == "api" {
+			return "go: github.com/gorilla/mux: no matching versions\n", errors.New("exit status 1")
+		}
+		return "go: added github.com/gorilla/mux v1.0.0\n", nil
+	}}
+	actual := testutils.Execute(NewRootCmd(db, runner), "get", "-C", dir, "--all-modules", "github.com/gorilla/mux")
+
+	assert.Equal(t, "api: go: github.com/gorilla/mux: no matching versions\n"+
+		"go: added github.com/gorilla/mux v1.0.0\nworker: ok\n"+
+		"go get failed in 1 of 2 modules\n", actual)
+	logs, err := db.SelectAccessLog(ctx, "github.com/gorilla/mux")
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, filepath.Join(dir, "worker"), logs[0].Projectdir)
+}
+
+func TestGetWorkspaceDryRun(t *testing.T) {
+	dir := testutils.MockProject(t)
+	db, _ := testutils.InMemDb(t)
+
+	runner := &testutils.FakeRunner{}
+	actual := testutils.Execute(NewRootCmd(db, runner), "get", "-n", "-C", dir, "--all-modules", "-u")
+
+	assert.Equal(t, fmt.Sprintf("go -C %s get -u\ngo -C %s get -u\n", filepath.Join(dir, "api"), filepath.Join(dir, "worker")), actual)
+	// the go.work is found without running the go command
+	assert.Empty(t, runner.Calls)
+}
+
+func TestGetWorkspaceErrors(t *testing.T) {
+	dir := testutils.MockProject(t)
+	module := t.TempDir()
+	err := os.WriteFile(filepath.Join(module, "go.mod"), []byte("module example.com/app\n"), 0o644)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		args     []string
+		gowork   string
+		expected string
+	}{
+		{
+			name:     "Errors when both flags are used",
+			args:     []string{"get", "-C", dir, "--all-modules", "--workspace-module", "api", "mux"},
+			expected: "--workspace-module and --all-modules can't be used together\n",
+		},
+		{
+			name:     "Errors with a module not in the workspace",
+			args:     []string{"get", "-C", dir, "--workspace-module", "api,billing", "mux"},
+			expected: fmt.Sprintf("billing is not a module of %s, the modules are api, worker\n", filepath.Join(dir, "go.work")),
+		},
+		{
+			name:     "Errors without a go.work",
+			args:     []string{"get", "-C", module, "--all-modules", "mux"},
+			expected: fmt.Sprintf("no go.work found in %s or any of it's parent dirs\n", module),
+		},
+		{
+			name:     "Errors when workspaces are turned off",
+			args:     []string{"get", "-C", dir, "--all-modules", "mux"},
+			gowork:   "off",
+			expected: fmt.Sprintf("no go.work found in %s or any of it's parent dirs\n", dir),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.gowork != "" {
+				t.Setenv("GOWORK", tt.gowork)
+			}
+			db, ctx := testutils.InMemDb(t)
+			_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 1.0})
+			assert.NoError(t, err)
+
+			runner := &testutils.FakeRunner{}
+			actual := testutils.Execute(NewRootCmd(db, runner), tt.args...)
+
+			assert.Equal(t, tt.expected, actual)
+			for _, command := range runner.Commands() {
+				assert.NotContains(t, command, "get")
+			}
+		})
+	}
+}
+
+func TestGetEndToEndWorkspace(t *testing.T) {
+	testutils.MockGoProxy(t, testutils.ProxyModule{Path: "github.com/gorilla/mux", Version: "v1.8.1"})
+	// the go.work has to be found by the go command itself
+	t.Setenv("GOWORK", "")
+
+	dir := testutils.MockWorkspace(t, "api", "worker")
+	testutils.Chdir(t, filepath.Join(dir, "api"))
+	db, _ := testutils.InMemDb(t)
+
+	cmd := NewRootCmd(db, toolchain.Exec{})
+	actual := testutils.Execute(cmd, "get", "--all-modules", "github.com/gorilla/mux")
+
+	assert.Contains(t, actual, "api: ok\n")
+	assert.Contains(t, actual, "worker: ok\n")
+	for _, module := range []string{"api", "worker"} {
+		goMod, err := os.ReadFile(filepath.Join(dir, module, "go.mod"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(goMod), "github.com/gorilla/mux v1.8.1", module)
+	}
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/vague2k/rummage/pkg/commands"
+	"github.com/vague2k/rummage/pkg/database"
+)
+
+func newPinCmd(db *database.Queries, ctx context.Context) *cobra.Command {
+	pinCmd := &cobra.Command{
+		Use:   "pin [entry] [version]",
+		Short: "Pin the version 'get' uses for an entry when no version is given",
+		Run: func(cmd *cobra.Command, args []string) {
+			commands.Pin(cmd, args, db, ctx)
+		},
+	}
+
+	pinCmd.Flags().Bool("clear", false, "Remove the pinned version of the entry")
+
+	return pinCmd
+}
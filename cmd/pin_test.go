@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/testutils"
+)
+
+func TestPin(t *testing.T) {
+	addItems := func(t *testing.T) (*database.Queries, context.Context) {
+		db, ctx := testutils.InMemDb(t)
+		for _, entry := range []string{"github.com/gorilla/mux", "github.com/charmbracelet/bubbletea"} {
+			_, err := db.AddItem(ctx, database.AddItemParams{Entry: entry, Score: 1.0})
+			assert.NoError(t, err)
+		}
+		return db, ctx
+	}
+
+	t.Run("Can pin an entry", func(t *testing.T) {
+		db, ctx := addItems(t)
+		actual := testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "pin", "mux", "v1.8.0")
+		assert.Equal(t, "pinned github.com/gorilla/mux to v1.8.0\n", actual)
+
+		item, err := db.SelectItem(ctx, "github.com/gorilla/mux")
+		assert.NoError(t, err)
+		assert.Equal(t, "v1.8.0", item.Pinned)
+	})
+
+	t.Run("Can show and clear a pin", func(t *testing.T) {
+		db, ctx := addItems(t)
+		actual := testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "pin", "github.com/gorilla/mux")
+		assert.Equal(t, "github.com/gorilla/mux is not pinned\n", actual)
+
+		testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "pin", "mux", "9a1b2c3")
+		actual = testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "pin", "mux")
+		assert.Equal(t, "github.com/gorilla/mux is pinned to 9a1b2c3\n", actual)
+
+		actual = testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "pin", "--clear", "mux")
+		assert.Equal(t, "unpinned github.com/gorilla/mux\n", actual)
+		item, err := db.SelectItem(ctx, "github.com/gorilla/mux")
+		assert.NoError(t, err)
+		assert.Equal(t, "", item.Pinned)
+	})
+}
+
+func TestPinErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "Errors without an entry",
+			args:     []string{"pin"},
+			expected: "pin needs an entry, and optionally the version to pin it to\n",
+		},
+		{
+			name:     "Errors if item does not exist",
+			args:     []string{"pin", "fiber", "v2.0.0"},
+			expected: "no match found with the given arguement fiber\n",
+		},
+		{
+			name:     "Errors when pinning to a query",
+			args:     []string{"pin", "mux", "latest"},
+			expected: "can't pin github.com/gorilla/mux to latest, pins have to be a version, commit hash or branch\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, ctx := testutils.InMemDb(t)
+			_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 1.0})
+			assert.NoError(t, err)
+
+			actual := testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), tt.args...)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
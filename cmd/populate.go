@@ -2,24 +2,29 @@ package cmd
 
 import (
 	"context"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/vague2k/rummage/pkg/commands"
 	"github.com/vague2k/rummage/pkg/database"
-	"github.com/vague2k/rummage/utils"
+	"github.com/vague2k/rummage/pkg/toolchain"
 )
 
-func newPopulateCmd(db *database.Queries, ctx context.Context) *cobra.Command {
+func newPopulateCmd(db *database.Queries, ctx context.Context, runner toolchain.GoRunner) *cobra.Command {
 	populateCmd := &cobra.Command{
-		Use:   "populate",
+		Use:   "populate [dirs...]",
 		Short: "Populate the database with third party packages already known by go",
 		Run: func(cmd *cobra.Command, args []string) {
-			commands.Populate(cmd, args, db, ctx)
+			commands.Populate(cmd, args, db, ctx, runner)
 		},
 	}
 
-	populateCmd.Flags().StringP("dir", "d", filepath.Join(utils.UserGoPath(), "pkg", "mod", "github.com"), "The directory you want to search for valid go packages")
+	populateCmd.Flags().StringP("dir", "d", "", "The directory you want to search for valid go packages, defaults to the module cache (see 'go env GOMODCACHE')")
+
+	populateCmd.Flags().BoolP("verbose", "v", false, "List every entry that was added, boosted, already present or invalid")
+	populateCmd.Flags().Bool("full", false, "Scan every module in the module cache, instead of only the ones downloaded since the last scan")
+	populateCmd.Flags().Bool("project", false, "Populate from the go.mod, go.sum and go.work files of the given project dirs instead, defaults to the working directory")
+	populateCmd.Flags().Bool("imports", false, "Populate from the import statements of the .go files in the given dirs instead, defaults to the working directory")
+	populateCmd.Flags().Float64("boost", 2.0, "How much the score of modules directly required by a project is boosted, only used with --project")
 
 	return populateCmd
 }
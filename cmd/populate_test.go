@@ -1,16 +1,22 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vague2k/rummage/pkg/database"
 	"github.com/vague2k/rummage/testutils"
 )
 
 func TestPopulate(t *testing.T) {
 	t.Run("Can populate db with 3 out of 3 packages", func(t *testing.T) {
 		db, _ := testutils.InMemDb(t)
-		cmd := NewRootCmd(db)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
 		actual := testutils.Execute(cmd, "populate", "--dir="+testutils.Mock3outof3pkgs(t))
 
 		assert.Equal(t, "added 3 packages\n", actual)
@@ -18,17 +24,240 @@ func TestPopulate(t *testing.T) {
 
 	t.Run("Can populate db with 1 out of 3 packages", func(t *testing.T) {
 		db, _ := testutils.InMemDb(t)
-		cmd := NewRootCmd(db)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
 		actual := testutils.Execute(cmd, "populate", "--dir="+testutils.Mock1outof3pkgs(t))
 
-		assert.Equal(t, "added 1 packages\n", actual)
+		assert.Equal(t, "added 1 packages, 2 invalid\n", actual)
+	})
+
+	t.Run("Can list every entry in each bucket", func(t *testing.T) {
+		db, _ := testutils.InMemDb(t)
+		dir := testutils.Mock1outof3pkgs(t)
+		testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "populate", "--dir="+dir)
+		actual := testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "populate", "--full", "--verbose", "--dir="+dir)
+
+		expected := "already present:\n" +
+			"  github.com/dir0/child\n" +
+			"invalid:\n" +
+			"  github.com/dir1/child\n" +
+			"  github.com/dir2/child\n" +
+			"no new packages were found to populate the database, added 0 packages, 1 already present, 2 invalid\n"
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("Defaults to the module cache the go command uses", func(t *testing.T) {
+		db, _ := testutils.InMemDb(t)
+		dir := testutils.Mock3outof3pkgs(t)
+		runner := &testutils.FakeRunner{Respond: func(_ string, args ...string) (string, error) {
+			return fmt.Sprintf(`{"GOMODCACHE": %q, "GOPATH": %q}`, dir, filepath.Dir(filepath.Dir(dir))), nil
+		}}
+		actual := testutils.Execute(NewRootCmd(db, runner), "populate")
+
+		assert.Equal(t, "added 3 packages\n", actual)
+		assert.Equal(t, []string{"env -json GOPATH GOMODCACHE"}, runner.Commands())
+	})
+
+	t.Run("Can populate db with modules from every host", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "populate", "--dir="+testutils.MockModCache(t))
+
+		assert.Equal(t, "added 4 packages\n", actual)
+		for _, entry := range []string{"github.com/BurntSushi/toml", "golang.org/x/mod", "gopkg.in/yaml.v3", "go.uber.org/zap"} {
+			_, err := db.SelectItem(ctx, entry)
+			assert.NoError(t, err)
+		}
+		_, err := db.SelectItem(ctx, "github.com/gorilla/mux")
+		assert.Error(t, err)
+	})
+
+	t.Run("Re-runs only look at modules downloaded since the last scan", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+		dir := testutils.Mock3outof3pkgs(t)
+		actual := testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "populate", "--dir="+dir)
+		assert.Equal(t, "added 3 packages\n", actual)
+
+		// pretend the scanned modules were downloaded long ago, and forget about one of them
+		old := time.Now().Add(-time.Hour)
+		for i := range 3 {
+			err := os.Chtimes(filepath.Join(dir, "cache", "download", "github.com", fmt.Sprintf("dir%d", i), "child", "@v"), old, old)
+			assert.NoError(t, err)
+		}
+		_, err := db.DeleteItem(ctx, "github.com/dir0/child")
+		assert.NoError(t, err)
+
+		actual = testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "populate", "--dir="+dir)
+		assert.Equal(t, "no new packages were found to populate the database, added 0 packages\n", actual)
+
+		actual = testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "populate", "--full", "--dir="+dir)
+		assert.Equal(t, "added 1 packages, 2 already present\n", actual)
+	})
+
+	t.Run("Modules that can't be read fail without stopping the rest", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+		dir := testutils.Mock3outof3pkgs(t)
+		// a "list" that can't be read as a file
+		list := filepath.Join(dir, "cache", "download", "github.com", "dir1", "child", "@v", "list")
+		assert.NoError(t, os.Remove(list))
+		assert.NoError(t, os.Mkdir(list, os.ModePerm))
+
+		actual := testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "populate", "--dir="+dir)
+		assert.Contains(t, actual, "github.com/dir1/child: read "+list+": is a directory\n")
+		assert.Contains(t, actual, "added 2 packages, 1 failed\n")
+
+		// the failed module is picked up again once it can be read
+		_, err := db.SelectMeta(ctx, "populate:lastscan:"+filepath.Join(dir, "cache", "download"))
+		assert.Error(t, err)
 	})
 
 	t.Run("Db does not populate if items already exist", func(t *testing.T) {
 		db, _ := testutils.InMemDb(t)
-		cmd := NewRootCmd(db)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
 		actual := testutils.Execute(cmd, "populate", "--dir="+t.TempDir())
 
 		assert.Equal(t, "no new packages were found to populate the database, added 0 packages\n", actual)
 	})
 }
+
+func TestPopulateProject(t *testing.T) {
+	t.Run("Can populate db from a workspace", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "populate", "--project", testutils.MockProject(t))
+
+		assert.Equal(t, "added 4 packages\n", actual)
+		expected := map[string]float64{
+			"github.com/gorilla/mux": 3.0,
+			"github.com/spf13/cobra": 3.0,
+			"go.uber.org/zap":        3.0,
+			"go.uber.org/multierr":   1.0,
+		}
+		for entry, score := range expected {
+			item, err := db.SelectItem(ctx, entry)
+			assert.NoError(t, err)
+			assert.Equal(t, score, item.Score, entry)
+		}
+		_, err := db.SelectItem(ctx, "example.com/monorepo/worker")
+		assert.Error(t, err)
+		// only the go.mod of yaml.v3 is in go.sum, so none of it's code is used
+		_, err = db.SelectItem(ctx, "gopkg.in/yaml.v3")
+		assert.Error(t, err)
+	})
+
+	t.Run("Can populate db from a single module", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "populate", "--project", "--boost=1", filepath.Join(testutils.MockProject(t), "worker"))
+
+		assert.Equal(t, "added 3 packages\n", actual)
+		item, err := db.SelectItem(ctx, "go.uber.org/zap")
+		assert.NoError(t, err)
+		assert.Equal(t, 2.0, item.Score)
+		item, err = db.SelectItem(ctx, "github.com/spf13/cobra")
+		assert.NoError(t, err)
+		assert.Equal(t, 1.0, item.Score)
+	})
+
+	t.Run("Existing packages are only boosted", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+		_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 1.0, Lastaccessed: 1})
+		assert.NoError(t, err)
+		_, err = db.AddItem(ctx, database.AddItemParams{Entry: "go.uber.org/zap", Score: 10.0, Lastaccessed: 1})
+		assert.NoError(t, err)
+
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "populate", "--project", testutils.MockProject(t))
+
+		assert.Equal(t, "added 2 packages, 1 boosted, 1 already present\n", actual)
+		item, err := db.SelectItem(ctx, "github.com/gorilla/mux")
+		assert.NoError(t, err)
+		assert.Equal(t, 3.0, item.Score)
+		item, err = db.SelectItem(ctx, "go.uber.org/zap")
+		assert.NoError(t, err)
+		assert.Equal(t, 10.0, item.Score)
+	})
+
+	t.Run("Modules shared by projects are seeded once", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+		dir := testutils.MockProject(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "populate", "--project", "-v", filepath.Join(dir, "worker"), filepath.Join(dir, "api"))
+
+		assert.Equal(t, 1, strings.Count(actual, "github.com/spf13/cobra\n"), actual)
+		assert.NotContains(t, actual, "already present")
+		item, err := db.SelectItem(ctx, "github.com/spf13/cobra")
+		assert.NoError(t, err)
+		assert.Equal(t, 3.0, item.Score)
+	})
+
+	t.Run("Dirs are relative to -C", func(t *testing.T) {
+		dir := testutils.MockProject(t)
+		for _, args := range [][]string{
+			{"-C", dir, "populate", "--project"},
+			{"populate", "-C", filepath.Dir(dir), "--project", filepath.Base(dir)},
+		} {
+			db, _ := testutils.InMemDb(t)
+			cmd := NewRootCmd(db, &testutils.FakeRunner{})
+			actual := testutils.Execute(cmd, args...)
+
+			assert.Equal(t, "added 4 packages\n", actual)
+		}
+	})
+
+	t.Run("Errors if -C is not a directory", func(t *testing.T) {
+		db, _ := testutils.InMemDb(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		missing := filepath.Join(t.TempDir(), "missing")
+		actual := testutils.Execute(cmd, "populate", "-C", missing, "--project")
+
+		assert.Equal(t, missing+" is not a directory\n", actual)
+	})
+
+	t.Run("Errors without go.mod or go.work", func(t *testing.T) {
+		db, _ := testutils.InMemDb(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		dir := t.TempDir()
+		actual := testutils.Execute(cmd, "populate", "--project", dir)
+
+		assert.Equal(t, dir+": no go.mod or go.work found\nno new packages were found to populate the database, added 0 packages, 1 failed\n", actual)
+	})
+}
+
+func TestPopulateImports(t *testing.T) {
+	t.Run("Can populate db from import statements", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "populate", "--imports", testutils.MockSourceTree(t))
+
+		assert.Equal(t, "added 2 packages\n", actual)
+		item, err := db.SelectItem(ctx, "golang.org/x/exp/slices")
+		assert.NoError(t, err)
+		assert.Equal(t, 2.0, item.Score)
+		item, err = db.SelectItem(ctx, "github.com/gorilla/mux")
+		assert.NoError(t, err)
+		assert.Equal(t, 1.0, item.Score)
+
+		items, err := db.SelectAllItems(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, items, 2)
+	})
+
+	t.Run("Usage is counted across dirs", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "populate", "--imports", testutils.MockSourceTree(t), testutils.MockSourceTree(t))
+
+		assert.Equal(t, "added 2 packages\n", actual)
+		item, err := db.SelectItem(ctx, "golang.org/x/exp/slices")
+		assert.NoError(t, err)
+		assert.Equal(t, 4.0, item.Score)
+	})
+
+	t.Run("Errors with both --project and --imports", func(t *testing.T) {
+		db, _ := testutils.InMemDb(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "populate", "--imports", "--project")
+
+		assert.Equal(t, "--project and --imports can't be used together\n", actual)
+	})
+}
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/vague2k/rummage/pkg/commands"
@@ -13,13 +15,17 @@ func newQueryCmd(db *database.Queries, ctx context.Context) *cobra.Command {
 		Use:   "query",
 		Short: "Query the database to find an entry by highest score, or using an exact match",
 		Run: func(cmd *cobra.Command, args []string) {
-			for _, arg := range args {
-				commands.Query(cmd, arg, db, ctx)
-			}
+			commands.Query(cmd, args, db, ctx)
 		},
 	}
 
-	queryCmd.Flags().IntP("quantity", "q", 10, "The amount of entry matches to display in the output")
+	queryCmd.Flags().IntP("quantity", "q", 10, "The amount of entry matches to display in the output, 0 or less displays every match")
+	queryCmd.Flags().StringP("format", "f", "table", fmt.Sprintf("The output format (%s), or a go template such as '{{.Entry}}'", strings.Join(commands.QueryFormats, ", ")))
+	queryCmd.Flags().Bool("raw", false, "Output the raw 'lastaccessed : score : entry' view, same as '--format raw'")
+	queryCmd.Flags().String("color", "auto", "When to color the table (auto, always, never), auto respects $NO_COLOR")
+	queryCmd.Flags().String("template", "", "The go template used with '--format template', it has access to .Entry, .Score, .Lastaccessed and .Pinned")
+	queryCmd.Flags().Bool("versions", false, "List every version of the matched entries seen in the module cache or through 'get', instead of the entries themselves")
+	queryCmd.Flags().BoolP("multi", "m", false, "Combine all args into one query, where each arg has to match after the previous one (e.g. 'charm bubbles')")
 
 	return queryCmd
 }
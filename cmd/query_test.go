@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vague2k/rummage/pkg/database"
@@ -35,8 +36,8 @@ func TestQuery(t *testing.T) {
 			assert.NoError(t, err)
 		}
 
-		cmd := NewRootCmd(db)
-		actual := testutils.Execute(cmd, "query", "mux")
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "query", "--raw", "mux")
 
 		s := strings.Builder{}
 		for i := 9; i > 0; i-- {
@@ -47,11 +48,339 @@ func TestQuery(t *testing.T) {
 		assert.Equal(t, expected, actual)
 	})
 
+	t.Run("Every match with a quantity of 0 or less", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+
+		for i := 1; i <= 12; i++ {
+			_, err := db.AddItem(ctx, database.AddItemParams{
+				Entry:        fmt.Sprintf("github.com/user%d/mux", i),
+				Score:        float64(i),
+				Lastaccessed: int64(i),
+			})
+			assert.NoError(t, err)
+		}
+
+		for _, quantity := range []string{"-1", "0"} {
+			cmd := NewRootCmd(db, &testutils.FakeRunner{})
+			actual := testutils.Execute(cmd, "query", "--raw", "-q", quantity, "mux")
+			assert.Equal(t, 12, strings.Count(actual, "/mux"))
+			assert.Less(t, strings.Index(actual, "github.com/user12/mux"), strings.Index(actual, "github.com/user1/mux"))
+		}
+	})
+
+	t.Run("Sorts matches using the chosen scorer", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+
+		now := time.Now().Unix()
+		items := []database.AddItemParams{
+			{Entry: "github.com/stale/mux", Score: 10.0, Lastaccessed: now - 60*60*24*30},
+			{Entry: "github.com/fresh/mux", Score: 3.0, Lastaccessed: now},
+		}
+		for _, item := range items {
+			_, err := db.AddItem(ctx, item)
+			assert.NoError(t, err)
+		}
+
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "query", "--scorer=frecency", "mux")
+
+		assert.Less(t, strings.Index(actual, "github.com/fresh/mux"), strings.Index(actual, "github.com/stale/mux"))
+	})
+
+	t.Run("Fuzzy matches abbreviations and typos", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+
+		_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/charmbracelet/bubbletea", Score: 1.0})
+		assert.NoError(t, err)
+		_, err = db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 1.0})
+		assert.NoError(t, err)
+
+		for _, arg := range []string{"btea", "bubletea"} {
+			cmd := NewRootCmd(db, &testutils.FakeRunner{})
+			actual := testutils.Execute(cmd, "query", "--raw", arg)
+			assert.Equal(t, "0 : 1.0000 : github.com/charmbracelet/bubbletea\n\n", actual)
+		}
+	})
+
+	t.Run("Better matches are ranked above items with a similar score", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+
+		_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gomodule/redix", Score: 2.0})
+		assert.NoError(t, err)
+		_, err = db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 1.0})
+		assert.NoError(t, err)
+
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "query", "mux")
+
+		assert.Less(t, strings.Index(actual, "github.com/gorilla/mux"), strings.Index(actual, "github.com/gomodule/redix"))
+	})
+
+	t.Run("Combines args into one query with --multi", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+
+		pkgs := []string{"github.com/charmbracelet/bubbles", "github.com/charmbracelet/bubbletea", "github.com/user/bubbles"}
+		for _, entry := range pkgs {
+			_, err := db.AddItem(ctx, database.AddItemParams{Entry: entry, Score: 1.0})
+			assert.NoError(t, err)
+		}
+
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "query", "--raw", "-m", "charm", "bubbles")
+		assert.Equal(t, "0 : 1.0000 : github.com/charmbracelet/bubbles\n\n", actual)
+
+		cmd = NewRootCmd(db, &testutils.FakeRunner{})
+		actual = testutils.Execute(cmd, "query", "--multi", "bubbles", "charm")
+		assert.Equal(t, "no match found with the given arguement bubbles charm\n", actual)
+	})
+
+	t.Run("Errors if the scorer does not exist", func(t *testing.T) {
+		db, _ := testutils.InMemDb(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "query", "--scorer=doesnotexist", "mux")
+
+		assert.Equal(t, "unknown scorer doesnotexist, valid scorers are default, frecency, decay\n", actual)
+	})
+
+	t.Run("Errors without args", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+		_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 1.0})
+		assert.NoError(t, err)
+
+		for _, args := range [][]string{{"query"}, {"query", "-m"}} {
+			cmd := NewRootCmd(db, &testutils.FakeRunner{})
+			actual := testutils.Execute(cmd, args...)
+			assert.Equal(t, "query needs at least one arguement to match entries against\n", actual)
+		}
+	})
+
 	t.Run("Errors if no match was found", func(t *testing.T) {
 		db, _ := testutils.InMemDb(t)
-		cmd := NewRootCmd(db)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
 		actual := testutils.Execute(cmd, "query", "mux")
 
 		assert.Equal(t, "no match found with the given arguement mux\n", actual)
 	})
 }
+
+func TestQueryTable(t *testing.T) {
+	addItems := func(t *testing.T) *database.Queries {
+		db, ctx := testutils.InMemDb(t)
+		now := time.Now().Unix()
+		items := []database.AddItemParams{
+			{Entry: "github.com/gorilla/mux", Score: 12.5, Lastaccessed: now - 60*60*3},
+			{Entry: "github.com/someone/with-a-really-long-name/mux", Score: 1.0, Lastaccessed: now - 60*60*24*15},
+		}
+		for _, item := range items {
+			_, err := db.AddItem(ctx, item)
+			assert.NoError(t, err)
+		}
+		return db
+	}
+
+	t.Run("Shows headers, ranks and relative times", func(t *testing.T) {
+		db := addItems(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "query", "mux")
+
+		expected := "#  LAST ACCESSED  SCORE    ENTRY\n" +
+			"1  3h ago         12.5000  github.com/gorilla/mux\n" +
+			"2  2w ago         1.0000   github.com/someone/with-a-really-long-name/mux\n"
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("Truncates long entries to fit the terminal", func(t *testing.T) {
+		t.Setenv("COLUMNS", "60")
+		db := addItems(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "query", "mux")
+
+		expected := "#  LAST ACCESSED  SCORE    ENTRY\n" +
+			"1  3h ago         12.5000  github.com/gorilla/mux\n" +
+			"2  2w ago         1.0000   ...ne/with-a-really-long-name/mux\n"
+		assert.Equal(t, expected, actual)
+		for _, line := range strings.Split(strings.TrimSpace(actual), "\n") {
+			assert.LessOrEqual(t, len(line), 60)
+		}
+	})
+
+	t.Run("Colors the table", func(t *testing.T) {
+		db := addItems(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "query", "--color=always", "mux")
+
+		assert.Contains(t, actual, "\x1b[1mENTRY\x1b[0m")
+		assert.Contains(t, actual, "\x1b[36mgithub.com/gorilla/mux\x1b[0m")
+	})
+
+	t.Run("Does not color the table when not printing to a terminal", func(t *testing.T) {
+		db := addItems(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "query", "mux")
+
+		assert.NotContains(t, actual, "\x1b[")
+	})
+
+	t.Run("Errors if the color mode does not exist", func(t *testing.T) {
+		db := addItems(t)
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "query", "--color=sometimes", "mux")
+
+		assert.Equal(t, "unknown color mode sometimes, valid modes are auto, always, never\n", actual)
+	})
+}
+
+func TestQueryFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name: "json",
+			args: []string{"query", "--format=json", "mux"},
+			expected: "[\n" +
+				"  {\n    \"entry\": \"github.com/gorilla/mux\",\n    \"score\": 2.5,\n    \"lastaccessed\": 2,\n    \"pinned\": \"v1.8.0\"\n  },\n" +
+				"  {\n    \"entry\": \"github.com/user/mux\",\n    \"score\": 1,\n    \"lastaccessed\": 1\n  }\n" +
+				"]\n",
+		},
+		{
+			name: "jsonl",
+			args: []string{"query", "-f", "jsonl", "mux"},
+			expected: "{\"entry\":\"github.com/gorilla/mux\",\"score\":2.5,\"lastaccessed\":2,\"pinned\":\"v1.8.0\"}\n" +
+				"{\"entry\":\"github.com/user/mux\",\"score\":1,\"lastaccessed\":1}\n",
+		},
+		{
+			name: "csv",
+			args: []string{"query", "--format=csv", "mux"},
+			expected: "entry,score,lastaccessed,pinned\n" +
+				"github.com/gorilla/mux,2.5,2,v1.8.0\n" +
+				"github.com/user/mux,1,1,\n",
+		},
+		{
+			name: "tsv",
+			args: []string{"query", "--format=tsv", "mux"},
+			expected: "entry\tscore\tlastaccessed\tpinned\n" +
+				"github.com/gorilla/mux\t2.5\t2\tv1.8.0\n" +
+				"github.com/user/mux\t1\t1\t\n",
+		},
+		{
+			name: "json with several args is a single array",
+			args: []string{"query", "--format=json", "gorilla", "mux"},
+			expected: "[\n" +
+				"  {\n    \"entry\": \"github.com/gorilla/mux\",\n    \"score\": 2.5,\n    \"lastaccessed\": 2,\n    \"pinned\": \"v1.8.0\"\n  },\n" +
+				"  {\n    \"entry\": \"github.com/user/mux\",\n    \"score\": 1,\n    \"lastaccessed\": 1\n  }\n" +
+				"]\n",
+		},
+		{
+			name: "csv with several args has a single header",
+			args: []string{"query", "--format=csv", "gorilla", "mux"},
+			expected: "entry,score,lastaccessed,pinned\n" +
+				"github.com/gorilla/mux,2.5,2,v1.8.0\n" +
+				"github.com/user/mux,1,1,\n",
+		},
+		{
+			name:     "Go template passed to --format",
+			args:     []string{"query", "--format={{.Entry}}", "mux"},
+			expected: "github.com/gorilla/mux\ngithub.com/user/mux\n",
+		},
+		{
+			name:     "template format with --template",
+			args:     []string{"query", "--format=template", "--template={{.Entry}} {{.Score}}", "mux"},
+			expected: "github.com/gorilla/mux 2.5\ngithub.com/user/mux 1\n",
+		},
+		{
+			name:     "Errors if the template format has no template",
+			args:     []string{"query", "--format=template", "mux"},
+			expected: "the template format needs a template, use --template or pass it to --format directly\n",
+		},
+		{
+			name:     "Errors if the format does not exist",
+			args:     []string{"query", "--format=xml", "mux"},
+			expected: "unknown format xml, valid formats are table, raw, json, jsonl, csv, tsv, template or a go template\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, ctx := testutils.InMemDb(t)
+			items := []database.AddItemParams{
+				{Entry: "github.com/gorilla/mux", Score: 2.5, Lastaccessed: 2},
+				{Entry: "github.com/user/mux", Score: 1.0, Lastaccessed: 1},
+			}
+			for _, item := range items {
+				_, err := db.AddItem(ctx, item)
+				assert.NoError(t, err)
+			}
+			_, err := db.PinItem(ctx, database.PinItemParams{Pinned: "v1.8.0", Entry: "github.com/gorilla/mux"})
+			assert.NoError(t, err)
+
+			cmd := NewRootCmd(db, &testutils.FakeRunner{})
+			actual := testutils.Execute(cmd, tt.args...)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestQueryVersions(t *testing.T) {
+	t.Run("Lists the versions seen in the module cache and through get", func(t *testing.T) {
+		t.Setenv("COLUMNS", "")
+		db, ctx := testutils.InMemDb(t)
+		testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "populate", "--dir="+testutils.MockModCache(t))
+		now := time.Now().Unix()
+		err := db.AddVersion(ctx, database.AddVersionParams{
+			Entry:     "go.uber.org/zap",
+			Version:   "v1.27.0",
+			Source:    "get",
+			Firstseen: now - 60*60*24*3,
+			Lastseen:  now - 60*60*24*3,
+		})
+		assert.NoError(t, err)
+
+		actual := testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "query", "--versions", "zap")
+		expected := "VERSION  SOURCE    FIRST SEEN  LAST SEEN  ENTRY\n" +
+			"v1.27.0  modcache  just now    just now   go.uber.org/zap\n" +
+			"v1.26.0  modcache  just now    just now   go.uber.org/zap\n" +
+			"v1.27.0  get       3d ago      3d ago     go.uber.org/zap\n"
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("Newer versions come first", func(t *testing.T) {
+		t.Setenv("COLUMNS", "")
+		db, ctx := testutils.InMemDb(t)
+		_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 1.0, Lastaccessed: 1})
+		assert.NoError(t, err)
+		now := time.Now().Unix()
+		for _, v := range []string{"v1.9.0", "v1.10.0", "v1.10.1-0.20240101000000-abcdefabcdef", "v1.2.0"} {
+			err := db.AddVersion(ctx, database.AddVersionParams{
+				Entry:     "github.com/gorilla/mux",
+				Version:   v,
+				Source:    "modcache",
+				Firstseen: now,
+				Lastseen:  now,
+			})
+			assert.NoError(t, err)
+		}
+
+		actual := testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "query", "--versions", "mux")
+		versions := []string{"v1.10.1-0.20240101000000-abcdefabcdef", "v1.10.0", "v1.9.0", "v1.2.0"}
+		for i := 1; i < len(versions); i++ {
+			assert.Less(t, strings.Index(actual, versions[i-1]+" "), strings.Index(actual, versions[i]+" "))
+		}
+	})
+
+	t.Run("Errors when no versions have been seen", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+		_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 1.0, Lastaccessed: 1})
+		assert.NoError(t, err)
+
+		actual := testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "query", "--versions", "mux")
+		assert.Equal(t, "no versions have been seen of the matched entries\n", actual)
+	})
+
+	t.Run("Errors with other formats", func(t *testing.T) {
+		db, _ := testutils.InMemDb(t)
+		actual := testutils.Execute(NewRootCmd(db, &testutils.FakeRunner{}), "query", "--versions", "--format=json", "mux")
+		assert.Equal(t, "--versions can only be used with the table format\n", actual)
+	})
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/vague2k/rummage/pkg/commands"
+	"github.com/vague2k/rummage/pkg/database"
+)
+
+func newRebuildCmd(db *database.Queries, ctx context.Context) *cobra.Command {
+	rebuildCmd := &cobra.Command{
+		Use:   "rebuild",
+		Short: "Rebuild the score of every entry from it's access history using the chosen scorer",
+		Run: func(cmd *cobra.Command, args []string) {
+			commands.Rebuild(cmd, args, db, ctx)
+		},
+	}
+
+	return rebuildCmd
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/testutils"
+)
+
+func TestRebuild(t *testing.T) {
+	t.Run("Rebuilds scores from access history", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+		now := time.Now().Unix()
+
+		_, err := db.AddItem(ctx, database.AddItemParams{Entry: "github.com/gorilla/mux", Score: 100.0, Lastaccessed: now})
+		assert.NoError(t, err)
+		_, err = db.AddItem(ctx, database.AddItemParams{Entry: "github.com/labstack/echo/v4", Score: 1.0, Lastaccessed: 0})
+		assert.NoError(t, err)
+
+		for _, ts := range []int64{now - 120, now - 60, now} {
+			err := db.LogAccess(ctx, database.LogAccessParams{
+				Entry:     "github.com/gorilla/mux",
+				Timestamp: ts,
+				Command:   "get",
+			})
+			assert.NoError(t, err)
+		}
+
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "rebuild", "--scorer=frecency")
+		assert.Equal(t, "rebuilt the score of 1 entries\n", actual)
+
+		item, err := db.SelectItem(ctx, "github.com/gorilla/mux")
+		assert.NoError(t, err)
+		assert.Equal(t, 4.0, item.Score)
+		assert.Equal(t, now, item.Lastaccessed)
+
+		untouched, err := db.SelectItem(ctx, "github.com/labstack/echo/v4")
+		assert.NoError(t, err)
+		assert.Equal(t, 1.0, untouched.Score)
+	})
+
+	t.Run("Skips history of removed entries", func(t *testing.T) {
+		db, ctx := testutils.InMemDb(t)
+
+		err := db.LogAccess(ctx, database.LogAccessParams{
+			Entry:     "github.com/gorilla/mux",
+			Timestamp: time.Now().Unix(),
+			Command:   "get",
+		})
+		assert.NoError(t, err)
+
+		cmd := NewRootCmd(db, &testutils.FakeRunner{})
+		actual := testutils.Execute(cmd, "rebuild")
+		assert.Equal(t, "rebuilt the score of 0 entries\n", actual)
+	})
+}
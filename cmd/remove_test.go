@@ -37,7 +37,7 @@ func TestRemove(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			cmd := NewRootCmd(db)
+			cmd := NewRootCmd(db, &testutils.FakeRunner{})
 			actual := testutils.Execute(cmd, tc.args...)
 			assert.Equal(t, tc.expected, actual)
 		})
@@ -83,7 +83,7 @@ func TestMultiRemove(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			cmd := NewRootCmd(db)
+			cmd := NewRootCmd(db, &testutils.FakeRunner{})
 			actual := testutils.Execute(cmd, tt.args...)
 			assert.Equal(t, tt.expected, actual)
 		})
@@ -2,12 +2,18 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/scoring"
+	"github.com/vague2k/rummage/pkg/toolchain"
 )
 
-func NewRootCmd(db *database.Queries) *cobra.Command {
+func NewRootCmd(db *database.Queries, runner toolchain.GoRunner) *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:     "rummage [command]",
 		Version: "v3.3.0",
@@ -19,12 +25,23 @@ func NewRootCmd(db *database.Queries) *cobra.Command {
 		},
 	}
 
+	defaultScorer := scoring.DefaultName
+	if env := os.Getenv("RUMMAGE_SCORER"); env != "" {
+		defaultScorer = env
+	}
+	rootCmd.PersistentFlags().String("scorer", defaultScorer, fmt.Sprintf("The scoring engine used to rank entries (%s), defaults to $RUMMAGE_SCORER if set", strings.Join(scoring.Names(), ", ")))
+	rootCmd.PersistentFlags().StringP("chdir", "C", "", "Run as if rummage was started in this directory instead of the working directory, like 'go -C'")
+	rootCmd.PersistentFlags().Duration("half-life", 7*24*time.Hour, "How long it takes for an entry's score to halve when using the 'decay' scorer")
+
 	ctx := context.Background()
-	rootCmd.AddCommand(newPopulateCmd(db, ctx))
+	rootCmd.AddCommand(newPopulateCmd(db, ctx, runner))
 	rootCmd.AddCommand(newQueryCmd(db, ctx))
 	rootCmd.AddCommand(newAddCmd(db, ctx))
 	rootCmd.AddCommand(newRemoveCmd(db, ctx))
-	rootCmd.AddCommand(newGetCmd(db, ctx))
+	rootCmd.AddCommand(newGetCmd(db, ctx, runner))
+	rootCmd.AddCommand(newPinCmd(db, ctx))
+	rootCmd.AddCommand(newRebuildCmd(db, ctx))
+	rootCmd.AddCommand(newDbCmd(db, ctx))
 
 	return rootCmd
 }
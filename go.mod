@@ -6,6 +6,7 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.32
 	github.com/spf13/cobra v1.10.1
 	github.com/stretchr/testify v1.11.0
+	golang.org/x/mod v0.26.0
 )
 
 require (
@@ -5,6 +5,7 @@ import (
 
 	"github.com/vague2k/rummage/cmd"
 	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/toolchain"
 )
 
 func main() {
@@ -13,7 +14,7 @@ func main() {
 		panic(err)
 	}
 
-	root := cmd.NewRootCmd(db)
+	root := cmd.NewRootCmd(db, toolchain.Exec{})
 	if err := root.Execute(); err != nil {
 		panic(err)
 	}
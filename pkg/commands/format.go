@@ -0,0 +1,328 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/term"
+	"github.com/vague2k/rummage/utils"
+)
+
+// Every format "query" can output in, see the "--format" flag
+var QueryFormats = []string{"table", "raw", "json", "jsonl", "csv", "tsv", "template"}
+
+// ansi escape codes used to color the table
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiCyan  = "\x1b[36m"
+)
+
+// how the human friendly table is drawn
+type tableStyle struct {
+	// the time last accessed times are relative to
+	now int64
+	// the width of the terminal, long entries are truncated to fit. 0 means entries are never truncated
+	width int
+	color bool
+}
+
+// how a single item is represented in json output
+type jsonItem struct {
+	Entry        string  `json:"entry"`
+	Score        float64 `json:"score"`
+	Lastaccessed int64   `json:"lastaccessed"`
+	Pinned       string  `json:"pinned,omitempty"`
+}
+
+// formats query results into a string that's ready to be printed
+type formatter func(items []database.RummageItem) (string, error)
+
+// Gets how the table should be drawn when printing to out.
+//
+// The width comes from $COLUMNS, or the terminal if out is one. With the "auto" color mode,
+// color is only used when out is a terminal and $NO_COLOR is not set
+func newTableStyle(out io.Writer, colorMode string) (tableStyle, error) {
+	style := tableStyle{now: time.Now().Unix()}
+
+	f, ok := out.(*os.File)
+	isTerminal := ok && term.IsTerminal(f)
+
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		style.width = columns
+	} else if isTerminal {
+		style.width, _ = term.Width(f)
+	}
+
+	switch colorMode {
+	case "auto":
+		style.color = isTerminal && os.Getenv("NO_COLOR") == ""
+	case "always":
+		style.color = true
+	case "never":
+		style.color = false
+	default:
+		return style, fmt.Errorf("unknown color mode %s, valid modes are auto, always, never", colorMode)
+	}
+
+	return style, nil
+}
+
+// Gets the formatter for a format, where format is one of QueryFormats.
+//
+// A format containing "{{" is used as a go template, the same as using the "template" format with tmpl.
+// Templates are executed once per item and have access to .Entry, .Score, .Lastaccessed and .Pinned
+func newFormatter(format, tmpl string, style tableStyle) (formatter, error) {
+	if strings.Contains(format, "{{") {
+		format, tmpl = "template", format
+	}
+
+	switch format {
+	case "table":
+		return func(items []database.RummageItem) (string, error) {
+			return formatTable(items, style), nil
+		}, nil
+	case "raw":
+		return func(items []database.RummageItem) (string, error) {
+			return formatRaw(items) + "\n", nil
+		}, nil
+	case "json":
+		return formatJSON, nil
+	case "jsonl":
+		return formatJSONL, nil
+	case "csv":
+		return func(items []database.RummageItem) (string, error) {
+			return formatDelimited(items, ',')
+		}, nil
+	case "tsv":
+		return func(items []database.RummageItem) (string, error) {
+			return formatDelimited(items, '\t')
+		}, nil
+	case "template":
+		if tmpl == "" {
+			return nil, fmt.Errorf("the template format needs a template, use --template or pass it to --format directly")
+		}
+		// templates are usually used for one line per item
+		if !strings.HasSuffix(tmpl, "\n") {
+			tmpl += "\n"
+		}
+		t, err := template.New("query").Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse template: %s", err)
+		}
+		return func(items []database.RummageItem) (string, error) {
+			return formatTemplate(t, items)
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown format %s, valid formats are %s or a go template", format, strings.Join(QueryFormats, ", "))
+}
+
+func toJSONItems(items []database.RummageItem) []jsonItem {
+	out := make([]jsonItem, len(items))
+	for i, item := range items {
+		out[i] = jsonItem{
+			Entry:        item.Entry,
+			Score:        item.Score,
+			Lastaccessed: item.Lastaccessed,
+			Pinned:       item.Pinned,
+		}
+	}
+	return out
+}
+
+func formatJSON(items []database.RummageItem) (string, error) {
+	b, err := json.MarshalIndent(toJSONItems(items), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+func formatJSONL(items []database.RummageItem) (string, error) {
+	var s strings.Builder
+	for _, item := range toJSONItems(items) {
+		b, err := json.Marshal(item)
+		if err != nil {
+			return "", err
+		}
+		s.Write(b)
+		s.WriteString("\n")
+	}
+	return s.String(), nil
+}
+
+func formatDelimited(items []database.RummageItem, delimiter rune) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = delimiter
+
+	records := [][]string{{"entry", "score", "lastaccessed", "pinned"}}
+	for _, item := range items {
+		records = append(records, []string{
+			item.Entry,
+			strconv.FormatFloat(item.Score, 'f', -1, 64),
+			strconv.FormatInt(item.Lastaccessed, 10),
+			item.Pinned,
+		})
+	}
+	if err := w.WriteAll(records); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func formatTemplate(t *template.Template, items []database.RummageItem) (string, error) {
+	var buf bytes.Buffer
+	for _, item := range items {
+		if err := t.Execute(&buf, item); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// formats items as a table meant for humans, with headers, rank numbers and relative times
+func formatTable(items []database.RummageItem, style tableStyle) string {
+	headers := []string{"#", "LAST ACCESSED", "SCORE", "ENTRY"}
+	rows := make([][]string, len(items))
+	for i, item := range items {
+		rows[i] = []string{
+			strconv.Itoa(i + 1),
+			utils.TimeAgo(item.Lastaccessed, style.now),
+			fmt.Sprintf("%.4f", item.Score),
+			item.Entry,
+		}
+	}
+
+	return drawTable(headers, rows, []string{ansiDim, ansiDim, "", ansiCyan}, style)
+}
+
+// formats every version seen of each item as a table meant for humans, most recently seen first
+func formatVersions(versions []database.RummageVersion, style tableStyle) string {
+	headers := []string{"VERSION", "SOURCE", "FIRST SEEN", "LAST SEEN", "ENTRY"}
+	rows := make([][]string, len(versions))
+	for i, v := range versions {
+		rows[i] = []string{
+			v.Version,
+			v.Source,
+			utils.TimeAgo(v.Firstseen, style.now),
+			utils.TimeAgo(v.Lastseen, style.now),
+			v.Entry,
+		}
+	}
+
+	return drawTable(headers, rows, []string{"", ansiDim, ansiDim, ansiDim, ansiCyan}, style)
+}
+
+// Draws a table with bold headers, where each column of a row is colored using colors.
+// The last column is expected to hold entries, which are truncated to fit the terminal
+func drawTable(headers []string, rows [][]string, colors []string, style tableStyle) string {
+	widths := make([]int, len(headers))
+	for _, row := range append([][]string{headers}, rows...) {
+		for i, col := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(col))
+		}
+	}
+
+	// every column but the entry has a fixed width, the entry gets what's left of the terminal
+	if style.width > 0 {
+		used := 0
+		for _, w := range widths[:len(widths)-1] {
+			used += w + len(columnGap)
+		}
+		available := max(style.width-used, minEntryWidth)
+		for _, row := range rows {
+			row[len(row)-1] = truncateLeft(row[len(row)-1], available)
+		}
+	}
+
+	var s strings.Builder
+	writeRow := func(row []string, colors []string) {
+		for i, col := range row {
+			last := i == len(row)-1
+			// the last column is not padded, so lines don't end in trailing spaces
+			if !last {
+				col = fmt.Sprintf("%-*s", widths[i], col)
+			}
+			if style.color && colors[i] != "" {
+				col = colors[i] + col + ansiReset
+			}
+			s.WriteString(col)
+			if !last {
+				s.WriteString(columnGap)
+			}
+		}
+		s.WriteString("\n")
+	}
+
+	bold := make([]string, len(headers))
+	for i := range bold {
+		bold[i] = ansiBold
+	}
+	writeRow(headers, bold)
+	for _, row := range rows {
+		writeRow(row, colors)
+	}
+
+	return s.String()
+}
+
+const (
+	columnGap = "  "
+	// entries are never truncated shorter than this, even if the terminal is really narrow
+	minEntryWidth = 20
+)
+
+// truncates s to at most n characters by cutting it's start, since the end of a module path is what tells modules apart
+func truncateLeft(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return "..." + string(r[len(r)-(n-3):])
+}
+
+// formats items the way rummage always has, "lastaccessed : score : entry" without any headers
+func formatRaw(items []database.RummageItem) string {
+	// formatting
+	var entryMaxLen, lastAccessedMaxLen, scoreMaxLen int
+	for _, item := range items {
+		entryLen := len(item.Entry)
+		scoreLen := len(fmt.Sprintf("%.4f", item.Score))
+		lastAccessedLen := len(fmt.Sprintf("%d", item.Lastaccessed))
+
+		if entryLen > entryMaxLen {
+			entryMaxLen = entryLen
+		}
+		if scoreLen > scoreMaxLen {
+			scoreMaxLen = scoreLen
+		}
+		if lastAccessedLen > lastAccessedMaxLen {
+			lastAccessedMaxLen = lastAccessedLen
+		}
+	}
+
+	var s strings.Builder
+	// Formatting output with proper padding
+	for _, item := range items {
+		s.WriteString(fmt.Sprintf(
+			"%-*d : %-*.*f : %-*s\n",
+			lastAccessedMaxLen, item.Lastaccessed,
+			scoreMaxLen, 4, item.Score,
+			entryMaxLen, item.Entry,
+		))
+	}
+	return s.String()
+}
@@ -3,159 +3,444 @@ package commands
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"os/exec"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/vague2k/rummage/pkg/database"
-	"github.com/vague2k/rummage/utils"
+	"github.com/vague2k/rummage/pkg/scoring"
+	"github.com/vague2k/rummage/pkg/toolchain"
 )
 
-// attempts to call "go get" against an arg and also takes into account any flags "go get" may accept
-func goGet(cobra *cobra.Command, pkg string, flags ...string) error {
-	cmd := exec.Command("go", "get")
-	if flags == nil && pkg != "" {
-		cmd.Args = append(cmd.Args, pkg)
+// calls "go get" once for every package through runner inside of dir, taking into account any flags "go get" may accept,
+// the output of "go get" is printed and returned
+func goGet(cmd *cobra.Command, ctx context.Context, runner toolchain.GoRunner, dir string, pkgs []string, flags ...string) (string, error) {
+	args := append([]string{"get"}, flags...)
+	args = append(args, pkgs...)
+
+	output, err := runner.Run(ctx, dir, args...)
+	if err != nil {
+		if output == "" {
+			return "", err
+		}
+		return "", fmt.Errorf("%s", output)
 	}
-	if pkg == "" {
-		cmd.Args = append(cmd.Args, flags...)
-	} else {
-		cmd.Args = append(cmd.Args, flags...)
-		cmd.Args = append(cmd.Args, pkg)
+
+	cmd.Print(output)
+	return output, nil
+}
+
+// Splits an arg like "mux@v1.8.0" into the name that's resolved through the database, and the version
+// (e.g. "v1.8.0", "latest", "upgrade", "patch", "none", a commit hash or a branch) that's passed along to "go get".
+//
+// Only the name is lowercased, since commit hashes and branches are case sensitive
+func splitVersion(arg string) (name string, version string) {
+	name, version, _ = strings.Cut(arg, "@")
+	return strings.ToLower(name), version
+}
+
+// Joins a package and a version back into the "module@version" form "go get" understands
+func withVersion(pkg, version string) string {
+	if version == "" {
+		return pkg
+	}
+	return pkg + "@" + version
+}
+
+// Uses the entry's pinned version when no version was asked for, reporting whether it did
+func pinnedVersion(item database.RummageItem, version string) (string, bool) {
+	if version != "" || item.Pinned == "" {
+		return version, false
+	}
+	return item.Pinned, true
+}
+
+// a package "get" resolved, and the version it's going to be fetched at
+type target struct {
+	// the arguement the package was resolved from
+	Arg     string
+	Item    database.RummageItem
+	Version string
+	// the item is already in the database
+	Known bool
+	// the rank the item was matched with, 0 when the package was given as a path
+	Rank float64
+	// the version is the one the item is pinned to
+	Pinned bool
+}
+
+// Resolves a package that's given as a path (e.g. "github.com/gorilla/mux"), which does not have to be in the database yet
+func resolvePath(cmd *cobra.Command, db *database.Queries, ctx context.Context, pkg, version string) (target, error) {
+	item, err := db.SelectItem(ctx, pkg)
+	if errors.Is(err, sql.ErrNoRows) {
+		return target{Arg: pkg, Item: database.RummageItem{Entry: pkg, Score: 1.0}, Version: version}, nil
 	}
+	if err != nil {
+		return target{}, err
+	}
+	t := target{Arg: pkg, Item: item, Known: true}
+	t.Version, t.Pinned = pinnedVersion(item, version)
+	return t, nil
+}
 
-	b, err := cmd.CombinedOutput()
-	output := string(b)
+// Resolves a package through the best fuzzy match in the db, taking into account the rank the scorer gives it
+func resolveMatch(cmd *cobra.Command, db *database.Queries, ctx context.Context, scorer scoring.Scorer, tokens []string, version string) (target, error) {
+	matches, err := findMatches(db, ctx, scorer, tokens...)
 	if err != nil {
-		return fmt.Errorf("%s", output)
+		return target{}, err
+	}
+	if len(matches) == 0 {
+		return target{}, fmt.Errorf("no match found with the given arguement %s", strings.Join(tokens, " "))
+	}
+	item, err := choose(cmd, matches)
+	if err != nil {
+		return target{}, err
+	}
+
+	t := target{Arg: strings.Join(tokens, " "), Item: item, Known: true}
+	t.Version, t.Pinned = pinnedVersion(item, version)
+	for _, m := range matches {
+		if m.Item.Entry == item.Entry {
+			t.Rank = m.Rank
+			break
+		}
+	}
+	return t, nil
+}
+
+// Gets the "go get" command line that fetches every package with flags, quoting any arg that needs it.
+// When dir is not empty, the command line runs inside of it with "go -C"
+func goGetCommandLine(dir string, pkgs []string, flags ...string) string {
+	args := []string{"get"}
+	if dir != "" {
+		args = []string{"-C", dir, "get"}
 	}
 
-	cobra.Print(output)
-	return nil
+	line := []string{"go"}
+	for _, arg := range append(append(args, flags...), pkgs...) {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$") {
+			arg = strconv.Quote(arg)
+		}
+		line = append(line, arg)
+	}
+	return strings.Join(line, " ")
 }
 
-// "go get"s a package and adds it to the database, and update it's score
+// Gets the packages "go get" is called with for every target
+func targetPkgs(targets []target) []string {
+	pkgs := make([]string, len(targets))
+	for i, t := range targets {
+		pkgs[i] = withVersion(t.Item.Entry, t.Version)
+	}
+	return pkgs
+}
+
+// Prints what each arguement resolved to, and the "go get" command line that would run in each dir, without running it
+func printDryRun(cmd *cobra.Command, dirs []string, targets []target, flags ...string) {
+	for _, t := range targets {
+		pinned := ""
+		if t.Pinned {
+			pinned = ", pinned to " + t.Version
+		}
+		switch {
+		case !t.Known:
+			cmd.Printf("%s -> %s (not in the database yet)\n", t.Arg, t.Item.Entry)
+		case t.Rank == 0:
+			cmd.Printf("%s -> %s (score %.4f%s)\n", t.Arg, t.Item.Entry, t.Item.Score, pinned)
+		default:
+			cmd.Printf("%s -> %s (matched with rank %.4f, score %.4f%s)\n", t.Arg, t.Item.Entry, t.Rank, t.Item.Score, pinned)
+		}
+	}
+	for _, dir := range dirs {
+		cmd.Println(goGetCommandLine(dir, targetPkgs(targets), flags...))
+	}
+}
+
+// "go get"s every target with a single "go get" call, so either all of them land in go.mod or none do.
 //
-// it's assumed that if this function is called, the item does not yet exist in the database
-func getAddedItem(cmd *cobra.Command, db *database.Queries, ctx context.Context, pkg string, flags ...string) {
-	err := goGet(cmd, pkg, flags...)
+// Only when "go get" succeeds is the database updated
+func getTargets(cmd *cobra.Command, db *database.Queries, ctx context.Context, runner toolchain.GoRunner, dir string, scorer scoring.Scorer, targets []target, flags ...string) error {
+	output, err := goGet(cmd, ctx, runner, dir, targetPkgs(targets), flags...)
 	if err != nil {
-		cmd.PrintErrf("%s\n", err)
-		return
+		return err
+	}
+	return recordGet(cmd, db, ctx, runner, scorer, targets, []string{dir}, fetchedVersions(output), flags...)
+}
+
+// Updates the database after "go get" fetched every target in each of dirs, where new packages are added,
+// every package's score is updated and the access is logged once, with every dir joined like $GOPATH is (e.g. "api:worker").
+// Removing a package with "@none" does not count as using it
+func recordGet(cmd *cobra.Command, db *database.Queries, ctx context.Context, runner toolchain.GoRunner, scorer scoring.Scorer, targets []target, dirs []string, fetched []moduleVersion, flags ...string) error {
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
+	qtx := db.WithTx(tx)
+
+	now := time.Now().Unix()
+	if err := recordVersions(qtx, ctx, versionSourceGet, fetched, now); err != nil {
+		return err
+	}
+
+	for _, t := range targets {
+		if t.Version == "none" {
+			continue
+		}
+
+		item := t.Item
+		if !t.Known {
+			item, err = qtx.AddItem(ctx, database.AddItemParams{
+				Entry:        item.Entry,
+				Score:        item.Score,
+				Lastaccessed: now,
+			})
+			if err != nil {
+				return err
+			}
+		}
 
-	item, err := db.AddItem(ctx, database.AddItemParams{
-		Entry:        pkg,
-		Score:        1.0,
-		Lastaccessed: time.Now().Unix(),
+		err = qtx.UpdateItem(ctx, database.UpdateItemParams{
+			Entry:        item.Entry,
+			Score:        scorer.Access(&item, now),
+			Lastaccessed: now,
+		})
+		if err != nil {
+			return err
+		}
+		// the access is recorded even when pinning fails, since "go get" already fetched it
+		if err := pinFetched(cmd, qtx, ctx, runner, dirs, item.Entry, t.Version, fetched); err != nil {
+			cmd.PrintErrf("%s\n", err)
+		}
+		// a single access is logged no matter how many dirs it was in, since it's only scored once
+		if err := logAccess(qtx, ctx, strings.Join(dirs, string(os.PathListSeparator)), item.Entry, now, flags...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// records that an entry was accessed with "get" inside of the project dir at now, so it's score can be rebuilt from history later.
+// now has to be the time the access was scored at, otherwise replaying the history gives a different score
+func logAccess(db *database.Queries, ctx context.Context, dir string, entry string, now int64, flags ...string) error {
+	return db.LogAccess(ctx, database.LogAccessParams{
+		Entry:      entry,
+		Timestamp:  now,
+		Projectdir: dir,
+		Command:    "get",
+		Flags:      strings.Join(flags, " "),
 	})
+}
+
+// helper function to get flags that could be used in a "go get" call, every flag that's set is used
+// (e.g. "rummage get -u -t mux" is "go get -u -t"), followed by any flag passed through after "--".
+//
+// The args before "--" are returned as the packages to resolve
+func getFlags(cmd *cobra.Command, args []string) (flags []string, pkgs []string, err error) {
+	flagUpdate, err := cmd.Flags().GetString("update")
+	if err != nil {
+		return nil, nil, err
+	}
+	switch flagUpdate {
+	case "":
+	case "true":
+		flags = append(flags, "-u")
+	case "patch":
+		flags = append(flags, "-u=patch")
+	default:
+		return nil, nil, fmt.Errorf("unknown update mode %s, -u can only be used as -u or -u=patch", flagUpdate)
+	}
+
+	for _, f := range []struct{ name, flag string }{{"dependencies", "-t"}, {"debug", "-x"}} {
+		v, err := cmd.Flags().GetBool(f.name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if v {
+			flags = append(flags, f.flag)
+		}
+	}
+
+	pkgs = args
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		pkgs = args[:dash]
+		flags = append(flags, args[dash:]...)
+	}
+
+	return flags, pkgs, nil
+}
+
+func handleFlagsNoPkg(cmd *cobra.Command, db *database.Queries, ctx context.Context, runner toolchain.GoRunner, dir string, flags ...string) {
+	output, err := goGet(cmd, ctx, runner, dir, nil, flags...)
 	if err != nil {
 		cmd.PrintErrf("%s\n", err)
 		return
 	}
 
-	err = db.UpdateItem(ctx, database.UpdateItemParams{
-		Entry:        item.Entry,
-		Score:        utils.RecalculateScore(&item),
-		Lastaccessed: time.Now().Unix(),
-	})
+	err = recordVersions(db, ctx, versionSourceGet, fetchedVersions(output), time.Now().Unix())
 	if err != nil {
 		cmd.PrintErrf("%s\n", err)
-		return
 	}
 }
 
-// "go get"s a package based on a db search and score, and at the end, update it's score
+// the "get" command attempts to get a package based on a couple criteria.
+//
+// If the arguement (not flag) passed to "get" has 2 slashes or more, it's used as is and "go get" is called upon
+// that arguement, where the item is added to the database if it does not exist in it yet
+//
+// If the arguement (not flag) passed looks more like a substring (e.g rummage get mux) then it's assumed the item
+// exists in the database and a fuzzy search (e.g. "btea" or "bubletea" both match "bubbletea")
+// combined with the item's score will be performed on that arguement.
+//
+// With the "--multi" flag, all arguements are combined into one query for a single package
+// (e.g. rummage get -m charm bubbles) where each arguement has to match after the previous one,
+// and the last arguement would rather match the last path segment.
+//
+// An arguement can end in a version like "go get" accepts (e.g. rummage get mux@v1.8.0 or mux@latest),
+// only the part before the '@' is resolved, and the version is passed along to "go get" as is.
+// With "--multi" the version goes on the last arguement (e.g. rummage get -m charm bubbles@latest)
+//
+// When several entries match with a similar rank (see "--pick-threshold"), or with the "--interactive" flag,
+// the user gets to pick which entry to get when stdin is a terminal.
 //
-// it's assumed that if this function is called, the item exists in the database
-func getHighestScore(cmd *cobra.Command, db *database.Queries, ctx context.Context, pkgSubstr string, flags ...string) {
-	item, err := db.EntryWithHighestScore(ctx, "%"+pkgSubstr+"%")
-	if err != nil && err == sql.ErrNoRows {
-		cmd.PrintErrf("no match found with the given arguement %s\n", pkgSubstr)
+// When no version is given, the entry's pinned version is used if it has one (see the "pin" command),
+// and the "--pin" flag pins the entry to whichever version was fetched.
+//
+// Any flag "go get" accepts can be passed through after "--" (e.g. rummage get mux -- -v -modfile=tools.mod),
+// and is combined with "-u", "-t" and "-x".
+//
+// Every arguement is resolved first, and then all of them are fetched with a single "go get" call.
+// Arguements resolving to the same entry are only fetched once, unless they ask for different versions of it which is an error.
+// With the "--dry-run" flag, what each arguement resolved to and the "go get" command line are printed instead,
+// without running the go command or touching the database.
+// With the global "-C" flag, "go get" runs inside of another module (e.g. rummage get -C services/billing mux) like "go -C" does,
+// and the access is recorded against that dir.
+//
+// In a go.work workspace, the "--workspace-module" flag (e.g. rummage get --workspace-module api,worker mux)
+// or the "--all-modules" flag runs "go get" in each chosen module instead, reporting whether it worked for each of them.
+// In all cases if a match for an arguement can't be found, the output will say so and nothing is fetched.
+//
+// Any error go get can output (e.g. "repository does not exist" or "malformed path") are outputted as expected
+// and rummage does not touch these kinds of errors
+func Get(cmd *cobra.Command, args []string, db *database.Queries, ctx context.Context, runner toolchain.GoRunner) {
+	flags, args, err := getFlags(cmd, args)
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
 		return
 	}
+
+	scorer, err := scorerFromFlags(cmd)
 	if err != nil {
 		cmd.PrintErrf("%s\n", err)
 		return
 	}
 
-	err = goGet(cmd, item.Entry, flags...)
+	flagDryRun, err := cmd.Flags().GetBool("dry-run")
 	if err != nil {
 		cmd.PrintErrf("%s\n", err)
 		return
 	}
 
-	err = db.UpdateItem(ctx, database.UpdateItemParams{
-		Entry:        item.Entry,
-		Score:        utils.RecalculateScore(&item),
-		Lastaccessed: time.Now().Unix(),
-	})
+	dir, err := projectDir(cmd)
 	if err != nil {
 		cmd.PrintErrf("%s\n", err)
 		return
 	}
-}
-
-// helper function to get flags that could be used in a "go get" call
-func getFlags(flagMap map[string]bool) []string {
-	var flags []string
-	if flagMap["update"] {
-		flags = append(flags, "-u")
-	} else if flagMap["dependencies"] {
-		flags = append(flags, "-t")
-	} else if flagMap["debug"] {
-		flags = append(flags, "-x")
-	} else {
-		flags = nil
+	// a dry run never runs the go command, so the go.work is looked for the same way it does instead
+	findWork := func(dir string) (string, error) {
+		return findGoWork(ctx, runner, dir)
 	}
-	return flags
-}
-
-func handleFlagsNoPkg(cmd *cobra.Command, flags ...string) {
-	err := goGet(cmd, "", flags...)
+	if flagDryRun {
+		findWork = lookGoWork
+	}
+	modules, err := workspaceModules(cmd, findWork, dir)
 	if err != nil {
 		cmd.PrintErrf("%s\n", err)
+		return
 	}
-}
 
-// the "get" command attempts to get a package based on a couple criteria.
-//
-// If the arguement (not flag) passed to "get" has 2 slashes or more, it's assumed the item does not yet exist
-// in the database and will add it to the database while also calling "go get" upon that arguement
-//
-// If the arguement (not flag) passed looks more like a substring (e.g rummage get mux) then it's assumed the item
-// exists in the database and a search based on highest score will be performed on that arguement.
-//
-// In both cases if a match for an arguement can't be found, the output will say so.
-//
-// Any error go get can output (e.g. "repository does not exist" or "malformed path") are outputted as expected
-// and rummage does not touch these kinds of errors
-func Get(cmd *cobra.Command, args []string, db *database.Queries, ctx context.Context) {
-	names := []string{"update", "dependencies", "debug"}
-	flagMap := make(map[string]bool)
-	for _, name := range names {
-		v, err := cmd.Flags().GetBool(name)
+	if len(args) == 0 && len(flags) > 0 {
+		switch {
+		case flagDryRun:
+			printDryRun(cmd, dryRunDirs(cmd, modules), nil, flags...)
+		case modules != nil:
+			err = getWorkspace(cmd, db, ctx, runner, modules, scorer, nil, flags...)
+		default:
+			handleFlagsNoPkg(cmd, db, ctx, runner, dir, flags...)
+		}
 		if err != nil {
 			cmd.PrintErrf("%s\n", err)
-			return
 		}
-		flagMap[name] = v
+		return
 	}
-	flags := getFlags(flagMap)
 
-	if len(args) == 0 && len(flags) > 0 {
-		handleFlagsNoPkg(cmd, flags...)
+	flagMulti, err := cmd.Flags().GetBool("multi")
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
 		return
 	}
 
-	for _, arg := range args {
-		arg = strings.ToLower(arg)
-		if strings.Count(arg, "/") >= 2 {
-			getAddedItem(cmd, db, ctx, arg, flags...)
-			continue
+	var targets []target
+	var failed bool
+	resolved := make(map[string]target)
+	addTarget := func(t target, err error) {
+		prev, seen := resolved[t.Item.Entry]
+		switch {
+		case err != nil:
+			cmd.PrintErrf("%s\n", err)
+			failed = true
+		case seen && prev.Version != t.Version:
+			cmd.PrintErrf("%s and %s both resolve to %s at different versions\n", withVersion(prev.Arg, prev.Version), withVersion(t.Arg, t.Version), t.Item.Entry)
+			failed = true
+		case !seen:
+			resolved[t.Item.Entry] = t
+			targets = append(targets, t)
 		}
-		getHighestScore(cmd, db, ctx, arg, flags...)
+	}
+
+	if flagMulti && len(args) > 0 {
+		tokens := make([]string, len(args))
+		for i, arg := range args {
+			tokens[i] = strings.ToLower(arg)
+		}
+		last, version := splitVersion(args[len(args)-1])
+		tokens[len(tokens)-1] = last
+		addTarget(resolveMatch(cmd, db, ctx, scorer, tokens, version))
+	} else {
+		for _, arg := range args {
+			name, version := splitVersion(arg)
+			if strings.Count(name, "/") >= 2 {
+				addTarget(resolvePath(cmd, db, ctx, name, version))
+				continue
+			}
+			addTarget(resolveMatch(cmd, db, ctx, scorer, []string{name}, version))
+		}
+	}
+
+	// nothing is fetched unless every arguement could be resolved
+	if failed || len(targets) == 0 {
+		return
+	}
+	if flagDryRun {
+		printDryRun(cmd, dryRunDirs(cmd, modules), targets, flags...)
+		return
+	}
+	for _, t := range targets {
+		if t.Pinned {
+			cmd.Printf("using pinned version %s of %s\n", t.Version, t.Item.Entry)
+		}
+	}
+	if modules != nil {
+		err = getWorkspace(cmd, db, ctx, runner, modules, scorer, targets, flags...)
+	} else {
+		err = getTargets(cmd, db, ctx, runner, dir, scorer, targets, flags...)
+	}
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
 	}
 }
@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/gomod"
+)
+
+// an import path, and how many files import it
+type importCount struct {
+	Path  string
+	Count int
+}
+
+// Third party import paths always start with a domain (e.g. "golang.org/x/exp/slices"),
+// while the standard library's never have a '.' in their first element
+func isThirdParty(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return strings.Contains(first, ".")
+}
+
+// Whether path is the module mod, or a package inside of it
+func inModule(path, mod string) bool {
+	return path == mod || strings.HasPrefix(path, mod+"/")
+}
+
+// Gets the module path of the nearest go.mod file at or above dir
+func enclosingModule(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if mod, err := gomod.ReadMod(filepath.Join(abs, "go.mod")); err == nil {
+			return mod.Module
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return ""
+		}
+		abs = parent
+	}
+}
+
+// Walks dir and counts how many .go files import each third party package.
+//
+// Only the import statements of each file are parsed. Like the go command, vendor and testdata dirs,
+// as well as dirs starting with '.' or '_' are skipped. Packages from the modules being scanned are left out
+func countImports(dir string) ([]importCount, error) {
+	own := []string{}
+	if mod := enclosingModule(dir); mod != "" {
+		own = append(own, mod)
+	}
+
+	counts := make(map[string]int)
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case name == "go.mod":
+			if mod, err := gomod.ReadMod(path); err == nil && mod.Module != "" {
+				own = append(own, mod.Module)
+			}
+			return nil
+		case filepath.Ext(name) != ".go":
+			return nil
+		}
+
+		f, err := parser.ParseFile(fset, path, nil, parser.ImportsOnly)
+		if err != nil {
+			// a file that does not parse should not stop the whole scan
+			return nil
+		}
+		for _, imp := range f.Imports {
+			p, err := strconv.Unquote(imp.Path.Value)
+			if err != nil || !isThirdParty(p) {
+				continue
+			}
+			counts[p]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var imports []importCount
+	for path, count := range counts {
+		isOwn := false
+		for _, mod := range own {
+			isOwn = isOwn || inModule(path, mod)
+		}
+		if !isOwn {
+			imports = append(imports, importCount{Path: path, Count: count})
+		}
+	}
+	sort.Slice(imports, func(i, j int) bool {
+		return imports[i].Path < imports[j].Path
+	})
+
+	return imports, nil
+}
+
+// Seeds the database with every third party package imported in dirs (defaults to the working directory),
+// where each package's score is the amount of files importing it
+func populateImports(dirs []string, db *database.Queries, ctx context.Context) (populateResult, error) {
+	var result populateResult
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	counts := make(map[string]int)
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			result.fail(dir, err)
+			continue
+		}
+		imports, err := countImports(dir)
+		if err != nil {
+			result.fail(dir, err)
+			continue
+		}
+		for _, imp := range imports {
+			counts[imp.Path] += imp.Count
+		}
+	}
+
+	seeds := make([]seed, 0, len(counts))
+	for path, count := range counts {
+		seeds = append(seeds, seed{Entry: path, Score: float64(count)})
+	}
+	sort.Slice(seeds, func(i, j int) bool {
+		return seeds[i].Entry < seeds[j].Entry
+	})
+
+	err := seedItems(db, ctx, seeds, &result)
+	return result, err
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/fuzzy"
+	"github.com/vague2k/rummage/pkg/picker"
+	"github.com/vague2k/rummage/pkg/scoring"
+	"github.com/vague2k/rummage/pkg/term"
+)
+
+// an item that fuzzily matched a query, alongside the rank it ended up with
+type match struct {
+	Item database.RummageItem
+	Rank float64
+}
+
+// Combines how well an entry matched (between 0 and 1) with the rank the scorer gave it.
+//
+// Match quality is squared so that a scattered match can't win over a clean one
+// unless it's been used a lot more
+func combine(quality, rank float64) float64 {
+	return quality * quality * (1 + rank)
+}
+
+// Fuzzy matches every item in the database against the query's tokens, and returns the matches
+// sorted from best to worst using both the match quality and the scorer's rank.
+//
+// When there's more than one token, each token has to match after the previous one, see fuzzy.MatchTokens
+func findMatches(db *database.Queries, ctx context.Context, scorer scoring.Scorer, tokens ...string) ([]match, error) {
+	items, err := db.SelectAllItems(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	var matches []match
+	for _, item := range items {
+		quality, ok := fuzzy.MatchTokens(tokens, item.Entry)
+		if !ok {
+			continue
+		}
+		matches = append(matches, match{
+			Item: item,
+			Rank: combine(quality, scorer.Rank(&item, now)),
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Rank > matches[j].Rank
+	})
+
+	return matches, nil
+}
+
+// Chooses which match to use, by default the best match is chosen.
+//
+// If the "--interactive" flag is used, or the rank of the runner up is within "--pick-threshold"
+// of the best match, the user gets to pick instead. Picking only happens when stdin is a terminal that can be put in raw mode,
+// otherwise the best match is always chosen
+func choose(cmd *cobra.Command, matches []match) (database.RummageItem, error) {
+	flagInteractive, err := cmd.Flags().GetBool("interactive")
+	if err != nil {
+		return database.RummageItem{}, err
+	}
+	flagThreshold, err := cmd.Flags().GetFloat64("pick-threshold")
+	if err != nil {
+		return database.RummageItem{}, err
+	}
+
+	best := matches[0].Item
+	if len(matches) == 1 {
+		return best, nil
+	}
+	ambiguous := flagThreshold > 0 && matches[1].Rank >= matches[0].Rank*(1-flagThreshold)
+	if !flagInteractive && !ambiguous {
+		return best, nil
+	}
+
+	in, ok := cmd.InOrStdin().(*os.File)
+	if !ok || !term.IsTerminal(in) {
+		return best, nil
+	}
+
+	items := make([]database.RummageItem, len(matches))
+	for i, m := range matches {
+		items[i] = m.Item
+	}
+	item, err := picker.Pick(in, cmd.ErrOrStderr(), items)
+	if errors.Is(err, term.ErrRawUnsupported) {
+		return best, nil
+	}
+	return item, err
+}
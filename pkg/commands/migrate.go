@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/vague2k/rummage/pkg/database"
+)
+
+// The "db migrate" command applies pending schema migrations, or with "--status"
+// lists every migration and whether or not the database has applied it
+func Migrate(cmd *cobra.Command, args []string, db *database.Queries, ctx context.Context) {
+	flagStatus, err := cmd.Flags().GetBool("status")
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+
+	if flagStatus {
+		migrationStatus(cmd, db, ctx)
+		return
+	}
+
+	applied, err := db.Migrate(ctx)
+	for _, m := range applied {
+		cmd.Printf("applied migration %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+
+	version, err := db.SchemaVersion(ctx)
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+	if len(applied) == 0 {
+		cmd.Printf("database is already up to date at version %d\n", version)
+		return
+	}
+	cmd.Printf("database migrated to version %d\n", version)
+}
+
+func migrationStatus(cmd *cobra.Command, db *database.Queries, ctx context.Context) {
+	migrations, err := database.Migrations()
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+	version, err := db.SchemaVersion(ctx)
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+
+	pending := 0
+	for _, m := range migrations {
+		status := "applied"
+		if m.Version > version {
+			status = "pending"
+			pending++
+		}
+		cmd.Printf("%-7s : %04d_%s\n", status, m.Version, m.Name)
+	}
+	cmd.Printf("database is at version %d with %d pending migrations\n", version, pending)
+}
@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/scoring"
+	"github.com/vague2k/rummage/pkg/toolchain"
+)
+
+// Whether version is something that always resolves to the same code (a version, commit hash or branch),
+// and not a query like "latest" or "<v1.2.0" that "go get" resolves differently over time
+func isPinnable(version string) bool {
+	switch version {
+	case "", "latest", "upgrade", "patch", "none":
+		return false
+	}
+	return !strings.HasPrefix(version, "<") && !strings.HasPrefix(version, ">")
+}
+
+// Resolves an arg to an entry in the database, an exact entry is used as is while anything else gets the best fuzzy match
+func resolveEntry(db *database.Queries, ctx context.Context, scorer scoring.Scorer, arg string) (database.RummageItem, error) {
+	item, err := db.SelectItem(ctx, arg)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return item, err
+	}
+
+	matches, err := findMatches(db, ctx, scorer, arg)
+	if err != nil {
+		return database.RummageItem{}, err
+	}
+	if len(matches) == 0 {
+		return database.RummageItem{}, fmt.Errorf("no match found with the given arguement %s", arg)
+	}
+	return matches[0].Item, nil
+}
+
+// Gets the version of the module entry is in that the go command uses inside of dir, asked for with "go list -m all"
+// which prints every module in the build list as "path version". Empty when entry is not in any of them
+func requiredVersion(ctx context.Context, runner toolchain.GoRunner, dir, entry string) (string, error) {
+	output, err := runner.Run(ctx, dir, "list", "-m", "all")
+	if err != nil {
+		if output == "" {
+			return "", err
+		}
+		return "", fmt.Errorf("%s", strings.TrimSpace(output))
+	}
+
+	// the same as with the modules "go get" reports, the longest module containing entry is the one it's in
+	required, module := "", ""
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !inModule(entry, fields[0]) || len(fields[0]) <= len(module) {
+			continue
+		}
+		required, module = fields[1], fields[0]
+	}
+	return required, nil
+}
+
+// With the "--pin" flag, pins entry to the version "go get" reported fetching it at. When "go get" did not report anything
+// (e.g. the module was already at that version), the version that was asked for is pinned instead,
+// or the version the module is required at in the first of dirs that requires it when no version was asked for
+func pinFetched(cmd *cobra.Command, db *database.Queries, ctx context.Context, runner toolchain.GoRunner, dirs []string, entry, version string, fetched []moduleVersion) error {
+	flagPin, err := cmd.Flags().GetBool("pin")
+	if err != nil || !flagPin {
+		return err
+	}
+
+	// entries can be packages inside of a module, so the longest module containing entry is the one it came from
+	pinned, module := "", ""
+	for _, v := range fetched {
+		if inModule(entry, v.Path) && len(v.Path) > len(module) {
+			pinned, module = v.Version, v.Path
+		}
+	}
+	if pinned == "" && isPinnable(version) {
+		pinned = version
+	}
+	for _, dir := range dirs {
+		if pinned != "" {
+			break
+		}
+		if pinned, err = requiredVersion(ctx, runner, dir, entry); err != nil {
+			return err
+		}
+	}
+	if pinned == "" {
+		return fmt.Errorf("could not tell which version of %s was fetched, nothing was pinned", entry)
+	}
+
+	if _, err := db.PinItem(ctx, database.PinItemParams{Pinned: pinned, Entry: entry}); err != nil {
+		return err
+	}
+	cmd.Printf("pinned %s to %s\n", entry, pinned)
+	return nil
+}
+
+// The "pin" command sets the version "get" uses for an entry whenever no version is given,
+// so everyone fetching a package into a new project ends up on the same version (e.g. rummage pin mux v1.8.0).
+//
+// The entry is resolved the same way "get" resolves it. Without a version the current pin is shown,
+// and the "--clear" flag removes the pin
+func Pin(cmd *cobra.Command, args []string, db *database.Queries, ctx context.Context) {
+	if len(args) == 0 || len(args) > 2 {
+		cmd.PrintErrf("pin needs an entry, and optionally the version to pin it to\n")
+		return
+	}
+	flagClear, err := cmd.Flags().GetBool("clear")
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+
+	scorer, err := scorerFromFlags(cmd)
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+	item, err := resolveEntry(db, ctx, scorer, strings.ToLower(args[0]))
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+
+	switch {
+	case flagClear:
+		if _, err := db.PinItem(ctx, database.PinItemParams{Pinned: "", Entry: item.Entry}); err != nil {
+			cmd.PrintErrf("%s\n", err)
+			return
+		}
+		cmd.Printf("unpinned %s\n", item.Entry)
+	case len(args) == 1 && item.Pinned == "":
+		cmd.Printf("%s is not pinned\n", item.Entry)
+	case len(args) == 1:
+		cmd.Printf("%s is pinned to %s\n", item.Entry, item.Pinned)
+	default:
+		version := args[1]
+		if !isPinnable(version) {
+			cmd.PrintErrf("can't pin %s to %s, pins have to be a version, commit hash or branch\n", item.Entry, version)
+			return
+		}
+		if _, err := db.PinItem(ctx, database.PinItemParams{Pinned: version, Entry: item.Entry}); err != nil {
+			cmd.PrintErrf("%s\n", err)
+			return
+		}
+		cmd.Printf("pinned %s to %s\n", item.Entry, version)
+	}
+}
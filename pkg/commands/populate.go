@@ -2,100 +2,461 @@ package commands
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"io/fs"
+	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/spf13/cobra"
 	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/toolchain"
+	"github.com/vague2k/rummage/utils"
 )
 
-// package version (e.g. @v1.0.0) will be stripped, but packages such as "github.com/user/example/v2" are valid
-func cut(s string) string {
-	_, after, _ := strings.Cut(s, "mod/")
-	pkg, _, _ := strings.Cut(after, "@")
-	return pkg
-}
-
-func capitalize(author string) string {
+// The module cache escapes uppercase letters in module paths as '!' followed by the lowercase letter,
+// since not every file system is case sensitive (e.g. "github.com/!burnt!sushi/toml" is "github.com/BurntSushi/toml")
+func unescapePath(escaped string) (string, error) {
 	var result []rune
-	i := 0
-	for i < len(author) {
-		if author[i] == '!' && i+1 < len(author) {
-			result = append(result, rune(author[i+1]-32)) // convert to uppercase
-			i += 2
-		} else {
-			result = append(result, rune(author[i]))
-			i++
+	bang := false
+	for _, r := range escaped {
+		switch {
+		case bang && r >= 'a' && r <= 'z':
+			result = append(result, unicode.ToUpper(r))
+			bang = false
+		case bang:
+			return "", fmt.Errorf("invalid escaped module path %s", escaped)
+		case r == '!':
+			bang = true
+		case r >= 'A' && r <= 'Z':
+			// uppercase letters are always escaped, so this can't be a module path
+			return "", fmt.Errorf("invalid escaped module path %s", escaped)
+		default:
+			result = append(result, r)
 		}
 	}
-	return string(result)
+	if bang {
+		return "", fmt.Errorf("invalid escaped module path %s", escaped)
+	}
+	return string(result), nil
 }
 
-// Walks a dir and extracts packages valid as args in a "go get" command.
-func extractPackages(dir string) ([]string, error) {
-	var pkgs []string
-	seen := make(map[string]bool)
-	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+// a module found in the module cache's download index
+type module struct {
+	Path string
+	// every version of the module the index knows about, sorted
+	Versions []string
+	// why the versions of the module could not be read, if they couldn't
+	Err error
+}
+
+// Finds the module download index ("cache/download") for dir.
+//
+// dir can be the module cache itself, the download index, or a dir inside of the index (e.g. "cache/download/github.com").
+// The prefix is the part of a module path that's already in dir.
+func downloadIndex(dir string) (root string, prefix string) {
+	index := filepath.Join(dir, "cache", "download")
+	if info, err := os.Stat(index); err == nil && info.IsDir() {
+		return index, ""
+	}
+
+	slashed := filepath.ToSlash(dir) + "/"
+	if _, after, ok := strings.Cut(slashed, "cache/download/"); ok {
+		return dir, after
+	}
+	return dir, ""
+}
+
+// Walks the module download index for dir, and extracts every module in it.
+// Modules without any known version, or with a path that is not properly escaped, are extracted without versions,
+// and modules whose versions could not be read are extracted with the error that happened.
+//
+// Each module in the index has an "@v" dir that holds a "list" of versions, and a ".info" and ".mod" file
+// for each version that was downloaded. Both module paths and versions in file names are escaped, see unescapePath.
+//
+// Only "@v" dirs modified at or after the unix time since are looked at, since any newly downloaded version
+// touches it's module's "@v" dir. The versions of each module are read concurrently, and report is called
+// every time another module has been read
+func extractModules(dir string, since int64, report func(done, total int)) ([]module, error) {
+	root, prefix := downloadIndex(dir)
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var candidates, invalid []module
+	var vDirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if !d.IsDir() {
+		if !d.IsDir() || d.Name() != "@v" {
 			return nil
 		}
 
-		curr := cut(path)
-		if strings.Count(curr, "/") < 2 {
-			return nil
+		rel, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil {
+			return err
 		}
-
-		// keep track of items added to the slice, instead of walked
-		if seen[curr] {
-			return nil
+		modPath, err := unescapePath(prefix + filepath.ToSlash(rel))
+		if err != nil {
+			// not a real module, so there's no versions to read
+			invalid = append(invalid, module{Path: prefix + filepath.ToSlash(rel)})
+			return filepath.SkipDir
 		}
-		seen[curr] = true
 
-		normalized := capitalize(curr)
-
-		pkgs = append(pkgs, normalized)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().Unix() >= since {
+			candidates = append(candidates, module{Path: modPath})
+			vDirs = append(vDirs, path)
+		}
 
-		return nil
+		return filepath.SkipDir
 	})
 	if err != nil {
 		return nil, fmt.Errorf("could walk directory %s to extract packages", dir)
 	}
 
-	return pkgs, nil
+	jobs := make(chan int)
+	finished := make(chan struct{})
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), len(candidates)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				candidates[i].Versions, candidates[i].Err = indexedVersions(vDirs[i])
+				finished <- struct{}{}
+			}
+		}()
+	}
+	go func() {
+		for i := range candidates {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(finished)
+	}()
+
+	done := 0
+	for range finished {
+		done++
+		report(done, len(candidates))
+	}
+
+	return append(candidates, invalid...), nil
+}
+
+// Gets every version of a module found in it's "@v" dir in the download index
+func indexedVersions(dir string) ([]string, error) {
+	seen := make(map[string]bool)
+
+	list, err := os.ReadFile(filepath.Join(dir, "list"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(list), "\n") {
+		if v := strings.TrimSpace(line); v != "" {
+			seen[v] = true
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".info" && ext != ".mod") {
+			continue
+		}
+		v, err := unescapePath(strings.TrimSuffix(entry.Name(), ext))
+		if err != nil {
+			continue
+		}
+		seen[v] = true
+	}
+
+	versions := make([]string, 0, len(seen))
+	for v := range seen {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+
+	return versions, nil
+}
+
+// The outcome of populating the database, where every entry ends up in exactly one bucket
+type populateResult struct {
+	Added   []string
+	Boosted []string
+	Present []string
+	Invalid []string
+	Failed  []populateFailure
+}
+
+// an entry (or a dir) that could not be populated
+type populateFailure struct {
+	Entry string
+	Err   error
+}
+
+func (r *populateResult) fail(entry string, err error) {
+	r.Failed = append(r.Failed, populateFailure{Entry: entry, Err: err})
+}
+
+// Prints every failure, followed by a summary of how many entries ended up in each bucket.
+// When verbose, every entry in each bucket is listed as well
+func printPopulateResult(cmd *cobra.Command, r populateResult, verbose bool) {
+	for _, f := range r.Failed {
+		cmd.PrintErrf("%s: %s\n", f.Entry, f.Err)
+	}
+
+	if verbose {
+		buckets := []struct {
+			name    string
+			entries []string
+		}{
+			{"added", r.Added},
+			{"boosted", r.Boosted},
+			{"already present", r.Present},
+			{"invalid", r.Invalid},
+		}
+		for _, b := range buckets {
+			if len(b.entries) == 0 {
+				continue
+			}
+			cmd.Printf("%s:\n", b.name)
+			for _, entry := range b.entries {
+				cmd.Printf("  %s\n", entry)
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("added %d packages", len(r.Added))
+	counts := []struct {
+		amt  int
+		name string
+	}{
+		{len(r.Boosted), "boosted"},
+		{len(r.Present), "already present"},
+		{len(r.Invalid), "invalid"},
+		{len(r.Failed), "failed"},
+	}
+	for _, c := range counts {
+		if c.amt > 0 {
+			summary += fmt.Sprintf(", %d %s", c.amt, c.name)
+		}
+	}
+
+	if len(r.Added) == 0 && len(r.Boosted) == 0 {
+		cmd.PrintErrf("no new packages were found to populate the database, %s\n", summary)
+	} else {
+		cmd.Printf("%s\n", summary)
+	}
 }
 
 // The "populate" command populates the database with third party packages already known by go.
-// By the default, the dir "populate" walks through will be "$GOPATH/pkg/mod/github.com"
-func Populate(cmd *cobra.Command, args []string, db *database.Queries, ctx context.Context) {
+// By the default, the dir "populate" looks through will be the module cache ("go env GOMODCACHE"),
+// where the download index ("cache/download") tells which modules have actually been downloaded.
+// Only modules downloaded since the last scan are looked at, unless "--full" is used.
+//
+// With the "--project" flag, "populate" instead seeds the database with the modules used by a project, see populateProject,
+// and with the "--imports" flag it seeds the database with the packages imported in go source trees, see populateImports.
+// Relative dirs are relative to the global "-C" flag when it's set
+func Populate(cmd *cobra.Command, args []string, db *database.Queries, ctx context.Context, runner toolchain.GoRunner) {
+	flagProject, err := cmd.Flags().GetBool("project")
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+	flagImports, err := cmd.Flags().GetBool("imports")
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+	flagVerbose, err := cmd.Flags().GetBool("verbose")
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+
+	// like the go command, every dir is relative to the "-C" dir
+	if _, err := projectDir(cmd); err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	for i := range args {
+		args[i] = inChdir(cmd, args[i])
+	}
+
+	var result populateResult
+	switch {
+	case flagProject && flagImports:
+		cmd.PrintErrf("--project and --imports can't be used together\n")
+		return
+	case flagProject:
+		result, err = populateProject(cmd, args, db, ctx)
+	case flagImports:
+		result, err = populateImports(args, db, ctx)
+	default:
+		result, err = populateModCache(cmd, db, ctx, runner)
+	}
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+
+	printPopulateResult(cmd, result, flagVerbose)
+}
+
+// Populates the database with every module in the module cache (or the "--dir" flag) downloaded since the last scan
+func populateModCache(cmd *cobra.Command, db *database.Queries, ctx context.Context, runner toolchain.GoRunner) (populateResult, error) {
+	var result populateResult
+
 	flagDir := cmd.Flag("dir").Value.String()
+	if flagDir == "" {
+		flagDir = utils.ModCache(ctx, runner)
+	} else {
+		flagDir = inChdir(cmd, flagDir)
+	}
+	flagFull, err := cmd.Flags().GetBool("full")
+	if err != nil {
+		return result, err
+	}
 
-	pkgs, err := extractPackages(flagDir)
+	// the time of the last scan is remembered per download index, so re-runs only look at modules downloaded since
+	root, _ := downloadIndex(flagDir)
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	scanKey := "populate:lastscan:" + root
+	var since int64
+	if !flagFull {
+		if value, err := db.SelectMeta(ctx, scanKey); err == nil {
+			since, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	scanStart := time.Now().Unix()
+
+	p := newProgress(cmd, "scanning modules")
+	modules, err := extractModules(flagDir, since, p.update)
+	p.done()
 	if err != nil {
-		cmd.PrintErr(err)
+		return result, err
 	}
 
-	amtAdded := 0
-	for _, entry := range pkgs {
-		_, err := db.AddItem(ctx, database.AddItemParams{
-			Entry:        entry,
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+	qtx := db.WithTx(tx)
+
+	for _, m := range modules {
+		if m.Err != nil {
+			result.fail(m.Path, m.Err)
+			continue
+		}
+		if len(m.Versions) == 0 {
+			result.Invalid = append(result.Invalid, m.Path)
+			continue
+		}
+		versions := make([]moduleVersion, len(m.Versions))
+		for i, v := range m.Versions {
+			versions[i] = moduleVersion{Path: m.Path, Version: v}
+		}
+		if err := recordVersions(qtx, ctx, versionSourceModCache, versions, scanStart); err != nil {
+			result.fail(m.Path, err)
+			continue
+		}
+
+		added, err := qtx.AddItemIfMissing(ctx, database.AddItemIfMissingParams{
+			Entry:        m.Path,
 			Score:        1.0,
-			Lastaccessed: time.Now().Unix(),
+			Lastaccessed: scanStart,
 		})
+		switch {
+		case err != nil:
+			result.fail(m.Path, err)
+		case added == 0:
+			result.Present = append(result.Present, m.Path)
+		default:
+			result.Added = append(result.Added, m.Path)
+		}
+	}
+
+	// modules that failed are older than this scan, so the scan is only remembered when they can't be skipped next time
+	if len(result.Failed) == 0 {
+		err = qtx.SetMeta(ctx, database.SetMetaParams{Key: scanKey, Value: strconv.FormatInt(scanStart, 10)})
 		if err != nil {
-			cmd.PrintErr(err)
+			return result, err
 		}
-		amtAdded++
 	}
+	return result, tx.Commit()
+}
 
-	if amtAdded == 0 {
-		cmd.PrintErrf("no new packages were found to populate the database, added %d packages\n", amtAdded)
-	} else {
-		cmd.Printf("added %d packages\n", amtAdded)
+// an entry to seed the database with, and the score it should at least have
+type seed struct {
+	Entry string
+	Score float64
+}
+
+// Seeds the database with entries, where new entries are added with their seeded score.
+// Entries that are already in the database are never lowered, their score only goes up to the seeded score.
+// Every entry is seeded in a single transaction
+func seedItems(db *database.Queries, ctx context.Context, seeds []seed, result *populateResult) error {
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := db.WithTx(tx)
+
+	now := time.Now().Unix()
+	for _, s := range seeds {
+		if err := utils.ResemblesGoPackage(s.Entry); err != nil {
+			result.Invalid = append(result.Invalid, s.Entry)
+			continue
+		}
+
+		existing, err := qtx.SelectItem(ctx, s.Entry)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			result.fail(s.Entry, err)
+			continue
+		}
+		isNew := errors.Is(err, sql.ErrNoRows)
+
+		err = qtx.SeedItem(ctx, database.SeedItemParams{
+			Entry:        s.Entry,
+			Score:        s.Score,
+			Lastaccessed: now,
+		})
+		switch {
+		case err != nil:
+			result.fail(s.Entry, err)
+		case isNew:
+			result.Added = append(result.Added, s.Entry)
+		case existing.Score < s.Score:
+			result.Boosted = append(result.Boosted, s.Entry)
+		default:
+			result.Present = append(result.Present, s.Entry)
+		}
 	}
+
+	return tx.Commit()
 }
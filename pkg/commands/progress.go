@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vague2k/rummage/pkg/term"
+)
+
+// A progress counter that redraws itself on a single line,
+// it's only shown when stderr is a terminal so it never ends up in logs or pipes
+type progress struct {
+	out     io.Writer
+	label   string
+	enabled bool
+	drawn   bool
+}
+
+func newProgress(cmd *cobra.Command, label string) *progress {
+	f, ok := cmd.ErrOrStderr().(*os.File)
+	return &progress{
+		out:     cmd.ErrOrStderr(),
+		label:   label,
+		enabled: ok && term.IsTerminal(f),
+	}
+}
+
+func (p *progress) update(done, total int) {
+	if !p.enabled {
+		return
+	}
+	fmt.Fprintf(p.out, "\r\x1b[K%s %d/%d", p.label, done, total)
+	p.drawn = true
+}
+
+// clears the counter, so whatever is printed next starts on a clean line
+func (p *progress) done() {
+	if p.drawn {
+		fmt.Fprint(p.out, "\r\x1b[K")
+		p.drawn = false
+	}
+}
@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/gomod"
+)
+
+// a module some project depends on
+type projectModule struct {
+	Path string
+	// the module is required directly by at least one of the project's go.mod files,
+	// and not just by an "// indirect" requirement or go.sum
+	Direct bool
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// Gets the global "-C" flag as it was given
+func flagChdir(cmd *cobra.Command) string {
+	if flag := cmd.Flag("chdir"); flag != nil {
+		return flag.Value.String()
+	}
+	return ""
+}
+
+// Gets the dir rummage works in, which is the "-C" flag when it's set or the working directory otherwise
+func projectDir(cmd *cobra.Command) (string, error) {
+	flag := flagChdir(cmd)
+	if flag == "" {
+		return os.Getwd()
+	}
+
+	dir, err := filepath.Abs(flag)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", flag)
+	}
+	return dir, nil
+}
+
+// Resolves a relative path against the "-C" flag the way the go command does, absolute paths are left as is
+func inChdir(cmd *cobra.Command, path string) string {
+	if flag := flagChdir(cmd); flag != "" && !filepath.IsAbs(path) {
+		return filepath.Join(flag, path)
+	}
+	return path
+}
+
+// Gets the dir of every module in a project, if the project has a go.work file
+// the modules are the ones it "use"s, otherwise the project is a single module
+func projectModuleDirs(dir string) ([]string, error) {
+	if work := filepath.Join(dir, "go.work"); fileExists(work) {
+		w, err := gomod.ReadWork(work)
+		if err != nil {
+			return nil, err
+		}
+		return w.ModuleDirs(dir), nil
+	}
+	if fileExists(filepath.Join(dir, "go.mod")) {
+		return []string{dir}, nil
+	}
+	return nil, fmt.Errorf("no go.mod or go.work found")
+}
+
+// Finds every module a project depends on, through it's go.mod, go.sum and go.work files.
+//
+// The project's own modules are left out, since they're not something you would "go get"
+func findProjectModules(dir string) ([]projectModule, error) {
+	modDirs, err := projectModuleDirs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	own := make(map[string]bool)
+	direct := make(map[string]bool)
+	sumFiles := []string{filepath.Join(dir, "go.work.sum")}
+	for _, modDir := range modDirs {
+		mod, err := gomod.ReadMod(filepath.Join(modDir, "go.mod"))
+		if err != nil {
+			return nil, err
+		}
+		own[mod.Module] = true
+		for _, req := range mod.Requires {
+			direct[req.Path] = direct[req.Path] || !req.Indirect
+		}
+		sumFiles = append(sumFiles, filepath.Join(modDir, "go.sum"))
+	}
+
+	for _, file := range sumFiles {
+		if !fileExists(file) {
+			continue
+		}
+		sums, err := gomod.ReadSum(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, sum := range sums {
+			// a module whose go.mod was only read to resolve versions is not something the project uses
+			if sum.GoModOnly {
+				continue
+			}
+			if _, ok := direct[sum.Path]; !ok {
+				direct[sum.Path] = false
+			}
+		}
+	}
+
+	var modules []projectModule
+	for path, d := range direct {
+		if own[path] {
+			continue
+		}
+		modules = append(modules, projectModule{Path: path, Direct: d})
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Path < modules[j].Path
+	})
+
+	return modules, nil
+}
+
+// Seeds the database with the modules of every project in dirs (defaults to the working directory).
+//
+// Every module starts with a score of 1.0, modules that are directly required get boosted on top of that
+func populateProject(cmd *cobra.Command, dirs []string, db *database.Queries, ctx context.Context) (populateResult, error) {
+	var result populateResult
+	flagBoost, err := cmd.Flags().GetFloat64("boost")
+	if err != nil {
+		return result, err
+	}
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	var seeds []seed
+	for _, dir := range dirs {
+		modules, err := findProjectModules(dir)
+		if err != nil {
+			result.fail(dir, err)
+			continue
+		}
+
+		for _, m := range modules {
+			score := 1.0
+			if m.Direct {
+				score += flagBoost
+			}
+			seeds = append(seeds, seed{Entry: m.Path, Score: score})
+		}
+	}
+
+	// projects can share modules, which are only seeded once so every entry ends up in a single bucket
+	err = seedItems(db, ctx, mergeSeeds(seeds), &result)
+	return result, err
+}
+
+// Merges every seed of the same entry into one with the highest score, keeping the order each entry was first seeded in
+func mergeSeeds(seeds []seed) []seed {
+	index := make(map[string]int)
+	var merged []seed
+	for _, s := range seeds {
+		i, ok := index[s.Entry]
+		if !ok {
+			index[s.Entry] = len(merged)
+			merged = append(merged, s)
+			continue
+		}
+		merged[i].Score = max(merged[i].Score, s.Score)
+	}
+	return merged
+}
@@ -7,63 +7,158 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/scoring"
 )
 
-// The Query command lets a user query the database against an arg to output a list of
-// entries (10 by default) sorted by score.
+// The Query command lets a user query the database against each arg to output a list of
+// entries (10 by default) that fuzzily match the arg, sorted by match quality and the rank the chosen scorer gives them.
 //
-// the quantity of matches in the output can be changed with the "--quantity" flag
-func Query(cmd *cobra.Command, arg string, db *database.Queries, ctx context.Context) {
-	quantityFlag, err := cmd.Flags().GetInt("quantity")
+// the quantity of matches in the output can be changed with the "--quantity" flag, and with the "--multi" flag
+// all args are combined into a single query where each arg has to match after the previous one.
+// The matches of every arg are output together, so "--format json" with several args is still a single json array.
+//
+// Results are printed as a table with relative times by default, "--raw" prints the raw timestamps and scores instead.
+// The "--format" flag can output them as json, jsonl, csv, tsv or using a go template so other programs can consume them,
+// and the "--versions" flag lists every version of the matched entries seen in the module cache or through "get" instead
+func Query(cmd *cobra.Command, args []string, db *database.Queries, ctx context.Context) {
+	// with no args, "--multi" would be a single empty query that matches every entry
+	if len(args) == 0 {
+		cmd.PrintErrf("query needs at least one arguement to match entries against\n")
+		return
+	}
+	flagMulti, err := cmd.Flags().GetBool("multi")
 	if err != nil {
 		cmd.PrintErrf("%s\n", err)
 		return
 	}
 
-	arg = strings.ToLower(arg)
-	items, err := db.FindTopNMatches(ctx, database.FindTopNMatchesParams{
-		Entry: "%" + arg + "%",
-		Limit: int64(quantityFlag),
-	})
+	flagFormat, err := cmd.Flags().GetString("format")
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+	flagTemplate, err := cmd.Flags().GetString("template")
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+	flagRaw, err := cmd.Flags().GetBool("raw")
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+	if flagRaw {
+		flagFormat = "raw"
+	}
+	flagColor, err := cmd.Flags().GetString("color")
 	if err != nil {
 		cmd.PrintErrf("%s\n", err)
 		return
-	} else if len(items) == 0 {
-		cmd.PrintErrf("%s %s\n", "no match found with the given arguement", arg)
+	}
+	style, err := newTableStyle(cmd.OutOrStdout(), flagColor)
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
 		return
 	}
 
-	cmd.Printf("%s\n", formatOutput(items))
-}
+	format, err := newFormatter(flagFormat, flagTemplate, style)
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+
+	flagVersions, err := cmd.Flags().GetBool("versions")
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+	if flagVersions {
+		if flagFormat != "table" {
+			cmd.PrintErrf("--versions can only be used with the table format\n")
+			return
+		}
+		format = func(items []database.RummageItem) (string, error) {
+			var versions []database.RummageVersion
+			for _, item := range items {
+				v, err := db.SelectVersions(ctx, item.Entry)
+				if err != nil {
+					return "", err
+				}
+				sortVersions(v)
+				versions = append(versions, v...)
+			}
+			if len(versions) == 0 {
+				return "", fmt.Errorf("no versions have been seen of the matched entries")
+			}
+			return formatVersions(versions, style), nil
+		}
+	}
 
-func formatOutput(items []database.RummageItem) string {
-	// formatting
-	var entryMaxLen, lastAccessedMaxLen, scoreMaxLen int
-	for _, item := range items {
-		entryLen := len(item.Entry)
-		scoreLen := len(fmt.Sprintf("%.4f", item.Score))
-		lastAccessedLen := len(fmt.Sprintf("%d", item.Lastaccessed))
+	flagQuantity, err := cmd.Flags().GetInt("quantity")
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+	scorer, err := scorerFromFlags(cmd)
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
 
-		if entryLen > entryMaxLen {
-			entryMaxLen = entryLen
+	queries := [][]string{args}
+	if !flagMulti {
+		queries = make([][]string, len(args))
+		for i, arg := range args {
+			queries[i] = []string{arg}
 		}
-		if scoreLen > scoreMaxLen {
-			scoreMaxLen = scoreLen
+	}
+
+	// every query's matches are formatted together, so structured formats stay a single valid document
+	var items []database.RummageItem
+	seen := make(map[string]bool)
+	for _, tokens := range queries {
+		matched, err := query(tokens, db, ctx, scorer, flagQuantity)
+		if err != nil {
+			cmd.PrintErrf("%s\n", err)
+			continue
 		}
-		if lastAccessedLen > lastAccessedMaxLen {
-			lastAccessedMaxLen = lastAccessedLen
+		for _, item := range matched {
+			if !seen[item.Entry] {
+				seen[item.Entry] = true
+				items = append(items, item)
+			}
 		}
 	}
+	if len(items) == 0 {
+		return
+	}
+
+	output, err := format(items)
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+	cmd.Print(output)
+}
 
-	var s strings.Builder
-	// Formatting output with proper padding
-	for _, item := range items {
-		s.WriteString(fmt.Sprintf(
-			"%-*d : %-*.*f : %-*s\n",
-			lastAccessedMaxLen, item.Lastaccessed,
-			scoreMaxLen, 4, item.Score,
-			entryMaxLen, item.Entry,
-		))
-	}
-	return s.String()
+// Gets up to quantity entries matching tokens, a quantity of 0 or less gets every match
+func query(tokens []string, db *database.Queries, ctx context.Context, scorer scoring.Scorer, quantity int) ([]database.RummageItem, error) {
+	for i := range tokens {
+		tokens[i] = strings.ToLower(tokens[i])
+	}
+	matches, err := findMatches(db, ctx, scorer, tokens...)
+	if err != nil {
+		return nil, err
+	} else if len(matches) == 0 {
+		return nil, fmt.Errorf("%s %s", "no match found with the given arguement", strings.Join(tokens, " "))
+	}
+
+	if quantity > 0 && len(matches) > quantity {
+		matches = matches[:quantity]
+	}
+	items := make([]database.RummageItem, len(matches))
+	for i, m := range matches {
+		items[i] = m.Item
+	}
+	return items, nil
 }
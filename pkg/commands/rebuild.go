@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/scoring"
+)
+
+// The "rebuild" command recalculates the score of every entry that has an access history,
+// by replaying each access through the chosen scorer.
+//
+// Entries without any history (e.g. entries that were only populated or added) are left untouched
+func Rebuild(cmd *cobra.Command, args []string, db *database.Queries, ctx context.Context) {
+	scorer, err := scorerFromFlags(cmd)
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+
+	logs, err := db.SelectAllAccessLogs(ctx)
+	if err != nil {
+		cmd.PrintErrf("%s\n", err)
+		return
+	}
+
+	// logs are sorted by entry, then timestamp
+	history := make(map[string][]int64)
+	var entries []string
+	for _, log := range logs {
+		if _, ok := history[log.Entry]; !ok {
+			entries = append(entries, log.Entry)
+		}
+		history[log.Entry] = append(history[log.Entry], log.Timestamp)
+	}
+
+	amtRebuilt := 0
+	for _, entry := range entries {
+		if _, err := db.SelectItem(ctx, entry); err != nil {
+			// the entry was removed from the database, but it's history is kept
+			continue
+		}
+
+		score, lastaccessed := scoring.Replay(scorer, history[entry])
+		err := db.UpdateItem(ctx, database.UpdateItemParams{
+			Entry:        entry,
+			Score:        score,
+			Lastaccessed: lastaccessed,
+		})
+		if err != nil {
+			cmd.PrintErrf("%s\n", err)
+			continue
+		}
+		amtRebuilt++
+	}
+
+	cmd.Printf("rebuilt the score of %d entries\n", amtRebuilt)
+}
@@ -0,0 +1,19 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vague2k/rummage/pkg/scoring"
+)
+
+// gets the scorer chosen with the "--scorer" and "--half-life" flags
+func scorerFromFlags(cmd *cobra.Command) (scoring.Scorer, error) {
+	name, err := cmd.Flags().GetString("scorer")
+	if err != nil {
+		return nil, err
+	}
+	halfLife, err := cmd.Flags().GetDuration("half-life")
+	if err != nil {
+		return nil, err
+	}
+	return scoring.New(name, int64(halfLife.Seconds()))
+}
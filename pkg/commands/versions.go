@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/vague2k/rummage/pkg/database"
+	"golang.org/x/mod/semver"
+)
+
+// where a version of an entry was seen
+const (
+	versionSourceModCache = "modcache"
+	versionSourceGet      = "get"
+)
+
+// a single version of a module
+type moduleVersion struct {
+	Path    string
+	Version string
+}
+
+// Parses the modules "go get" reports changing, which look like
+//
+//	go: added github.com/gorilla/mux v1.8.1
+//	go: upgraded golang.org/x/sys v0.1.0 => v0.2.0
+//	go: downgraded golang.org/x/sys v0.2.0 => v0.1.0
+//
+// only the version a module ended up at is kept
+func fetchedVersions(output string) []moduleVersion {
+	var versions []moduleVersion
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "go:" {
+			continue
+		}
+
+		switch {
+		case fields[1] == "added" && len(fields) == 4:
+			versions = append(versions, moduleVersion{Path: fields[2], Version: fields[3]})
+		case (fields[1] == "upgraded" || fields[1] == "downgraded") && len(fields) == 6 && fields[4] == "=>":
+			versions = append(versions, moduleVersion{Path: fields[2], Version: fields[5]})
+		}
+	}
+	return versions
+}
+
+// Records that each version was seen from source at the unix time now
+func recordVersions(db *database.Queries, ctx context.Context, source string, versions []moduleVersion, now int64) error {
+	for _, v := range versions {
+		err := db.AddVersion(ctx, database.AddVersionParams{
+			Entry:     v.Path,
+			Version:   v.Version,
+			Source:    source,
+			Firstseen: now,
+			Lastseen:  now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sorts versions the most recently seen first. Versions seen at the same time go from the newest version to the oldest,
+// which needs a semver comparison since "v1.10.0" sorts below "v1.9.0" as a string, and then by source
+func sortVersions(versions []database.RummageVersion) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, b := versions[i], versions[j]
+		if a.Lastseen != b.Lastseen {
+			return a.Lastseen > b.Lastseen
+		}
+		if c := semver.Compare(a.Version, b.Version); c != 0 {
+			return c > 0
+		}
+		// anything that's not a valid semver (e.g. a branch) is equal to semver.Compare
+		if a.Version != b.Version {
+			return a.Version > b.Version
+		}
+		return a.Source < b.Source
+	})
+}
@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/gomod"
+	"github.com/vague2k/rummage/pkg/scoring"
+	"github.com/vague2k/rummage/pkg/toolchain"
+)
+
+// a module "use"d by a go.work file
+type workspaceModule struct {
+	// the dir of the module as written in go.work, without the leading "./" (e.g. "services/billing")
+	Name string
+	Dir  string
+}
+
+// Finds the go.work file the go command uses inside of dir, which is either set through $GOWORK
+// or found in dir or any of it's parent dirs, so it's asked for with "go env GOWORK"
+func findGoWork(ctx context.Context, runner toolchain.GoRunner, dir string) (string, error) {
+	output, err := runner.Run(ctx, dir, "env", "GOWORK")
+	if err != nil {
+		if output == "" {
+			return "", err
+		}
+		return "", fmt.Errorf("%s", strings.TrimSpace(output))
+	}
+
+	work := strings.TrimSpace(output)
+	if work == "" || work == "off" {
+		return "", fmt.Errorf("no go.work found in %s or any of it's parent dirs", dir)
+	}
+	return work, nil
+}
+
+// Looks for the go.work file the go command would use inside of dir without running it, which is $GOWORK when it's set,
+// or the first go.work found in dir or any of it's parent dirs. Only "go env -w GOWORK=..." is not taken into account
+func lookGoWork(dir string) (string, error) {
+	switch work := os.Getenv("GOWORK"); work {
+	case "":
+	case "off":
+		return "", fmt.Errorf("no go.work found in %s or any of it's parent dirs", dir)
+	default:
+		return work, nil
+	}
+
+	for d := dir; ; {
+		work := filepath.Join(d, "go.work")
+		if info, err := os.Stat(work); err == nil && !info.IsDir() {
+			return work, nil
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", fmt.Errorf("no go.work found in %s or any of it's parent dirs", dir)
+		}
+		d = parent
+	}
+}
+
+// Gets the modules of the go.work file used in dir that "get" should run in, chosen with the "--workspace-module"
+// or "--all-modules" flag. When neither flag is set, no modules are returned and "get" runs in dir as usual.
+//
+// The go.work file is found with findWork, see findGoWork and lookGoWork
+func workspaceModules(cmd *cobra.Command, findWork func(dir string) (string, error), dir string) ([]workspaceModule, error) {
+	flagModules, err := cmd.Flags().GetStringSlice("workspace-module")
+	if err != nil {
+		return nil, err
+	}
+	flagAll, err := cmd.Flags().GetBool("all-modules")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(flagModules) > 0 && flagAll:
+		return nil, fmt.Errorf("--workspace-module and --all-modules can't be used together")
+	case len(flagModules) == 0 && !flagAll:
+		return nil, nil
+	}
+
+	work, err := findWork(dir)
+	if err != nil {
+		return nil, err
+	}
+	w, err := gomod.ReadWork(work)
+	if err != nil {
+		return nil, err
+	}
+
+	// the modules a go.work uses are relative to the go.work, which can be in a parent of dir
+	dirs := w.ModuleDirs(filepath.Dir(work))
+	modules := make([]workspaceModule, len(w.Uses))
+	names := make([]string, len(w.Uses))
+	for i, use := range w.Uses {
+		names[i] = filepath.ToSlash(filepath.Clean(use))
+		modules[i] = workspaceModule{Name: names[i], Dir: dirs[i]}
+	}
+	if flagAll {
+		return modules, nil
+	}
+
+	var chosen []workspaceModule
+	for _, name := range flagModules {
+		name = filepath.ToSlash(filepath.Clean(name))
+		found := false
+		for _, m := range modules {
+			if m.Name == name {
+				chosen = append(chosen, m)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s is not a module of %s, the modules are %s", name, work, strings.Join(names, ", "))
+		}
+	}
+	return chosen, nil
+}
+
+// Gets the dirs the dry run prints a "go get" command line for, as they would be given to "go -C".
+// Module dirs are relative to the working directory when they're inside of it, and absolute otherwise
+func dryRunDirs(cmd *cobra.Command, modules []workspaceModule) []string {
+	if modules == nil {
+		return []string{flagChdir(cmd)}
+	}
+	wd, _ := os.Getwd()
+	dirs := make([]string, len(modules))
+	for i, m := range modules {
+		dirs[i] = m.Dir
+		if rel, err := filepath.Rel(wd, m.Dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			dirs[i] = rel
+		}
+	}
+	return dirs
+}
+
+// "go get"s every target in each module with a single "go get" call per module, reporting whether it worked for each of them.
+//
+// The database is updated once as long as "go get" worked in at least one module,
+// and the access is recorded against every module it worked in
+func getWorkspace(cmd *cobra.Command, db *database.Queries, ctx context.Context, runner toolchain.GoRunner, modules []workspaceModule, scorer scoring.Scorer, targets []target, flags ...string) error {
+	pkgs := targetPkgs(targets)
+
+	var dirs []string
+	var fetched []moduleVersion
+	for _, m := range modules {
+		output, err := goGet(cmd, ctx, runner, m.Dir, pkgs, flags...)
+		if err != nil {
+			cmd.PrintErrf("%s: %s\n", m.Name, strings.TrimSpace(err.Error()))
+			continue
+		}
+		cmd.Printf("%s: ok\n", m.Name)
+		dirs = append(dirs, m.Dir)
+		fetched = append(fetched, fetchedVersions(output)...)
+	}
+
+	if len(dirs) < len(modules) {
+		cmd.PrintErrf("go get failed in %d of %d modules\n", len(modules)-len(dirs), len(modules))
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+	return recordGet(cmd, db, ctx, runner, scorer, targets, dirs, fetched, flags...)
+}
@@ -3,7 +3,6 @@ package database
 import (
 	"context"
 	"database/sql"
-	_ "embed"
 	"fmt"
 	"log"
 	"os"
@@ -13,9 +12,6 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
-//go:embed schema.sql
-var schema string
-
 func Init(path string) (*Queries, error) {
 	if path == "" {
 		dataDir := userDataDir()
@@ -39,11 +35,17 @@ func Init(path string) (*Queries, error) {
 		return nil, fmt.Errorf("could not init rummage db: \n%s", err)
 	}
 
-	if _, err := db.ExecContext(context.Background(), schema); err != nil {
-		return nil, fmt.Errorf("could not create 'items' table in rummage db: \n%s", err)
+	if path == ":memory:" {
+		// every new connection to ":memory:" opens a brand new database
+		db.SetMaxOpenConns(1)
+	}
+
+	q := New(db)
+	if _, err := q.Migrate(context.Background()); err != nil {
+		return nil, fmt.Errorf("could not migrate rummage db: \n%s", err)
 	}
 
-	return New(db), nil
+	return q, nil
 }
 
 // Gets the user's $XDG_DATA_HOME dir.
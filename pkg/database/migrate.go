@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// A single versioned change to the database schema.
+//
+// Migrations live in the "migrations" dir and are named "<version>_<name>.sql", e.g. "0001_create_items.sql"
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Gets every embedded migration, sorted by version
+func Migrations() ([]Migration, error) {
+	files, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, file := range files {
+		version, name, ok := strings.Cut(strings.TrimSuffix(file.Name(), ".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s is not named <version>_<name>.sql", file.Name())
+		}
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s does not start with a version number", file.Name())
+		}
+		b, err := migrationFiles.ReadFile(path.Join("migrations", file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: v, Name: name, SQL: string(b)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Gets the version the database is currently at, this is the version of the last migration applied to it
+func (q *Queries) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := q.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version)
+	return version, err
+}
+
+// Begins a transaction on the underlying database, to be used with Queries.WithTx
+func (q *Queries) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	db, ok := q.db.(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("can't begin a transaction while already in one")
+	}
+	return db.BeginTx(ctx, nil)
+}
+
+// Applies every migration the database has not seen yet, each in it's own transaction.
+//
+// The version of the last applied migration is tracked using sqlite's "PRAGMA user_version",
+// and the migrations that were applied are returned
+func (q *Queries) Migrate(ctx context.Context) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := q.SchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest := migrations[len(migrations)-1].Version; current > latest {
+		return nil, fmt.Errorf("database is at version %d, but this version of rummage only knows up to version %d", current, latest)
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := q.apply(ctx, m); err != nil {
+			return applied, fmt.Errorf("could not apply migration %04d_%s: \n%s", m.Version, m.Name, err)
+		}
+		applied = append(applied, m)
+	}
+
+	return applied, nil
+}
+
+func (q *Queries) apply(ctx context.Context, m Migration) error {
+	tx, err := q.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return err
+	}
+	// pragmas can't take parameters
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", m.Version)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	assert.NoError(t, err)
+
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version, "migration versions should have no gaps")
+		assert.NotEmpty(t, m.Name)
+		assert.NotEmpty(t, m.SQL)
+	}
+}
+
+func TestMigrateExistingDatabase(t *testing.T) {
+	// a database created before migrations existed has the items table, but no user_version
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "rummage"), os.ModePerm))
+	old, err := sql.Open("sqlite3", filepath.Join(dir, "rummage", "rummage.db"))
+	assert.NoError(t, err)
+	_, err = old.Exec(`CREATE TABLE rummage_items (entry TEXT NOT NULL UNIQUE, score FLOAT NOT NULL, lastaccessed INTEGER NOT NULL);
+	INSERT INTO rummage_items VALUES ('github.com/gorilla/mux', 5.0, 1);`)
+	assert.NoError(t, err)
+	assert.NoError(t, old.Close())
+
+	q, err := Init(dir)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	migrations, err := Migrations()
+	assert.NoError(t, err)
+	version, err := q.SchemaVersion(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, migrations[len(migrations)-1].Version, version)
+
+	item, err := q.SelectItem(ctx, "github.com/gorilla/mux")
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, item.Score)
+
+	applied, err := q.Migrate(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestMigrateNewerDatabase(t *testing.T) {
+	q, err := Init(":memory:")
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = q.db.ExecContext(ctx, "PRAGMA user_version = 9999")
+	assert.NoError(t, err)
+
+	_, err = q.Migrate(ctx)
+	assert.ErrorContains(t, err, "database is at version 9999")
+}
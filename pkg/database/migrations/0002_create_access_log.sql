@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS rummage_access_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entry TEXT NOT NULL,
+    timestamp INTEGER NOT NULL,
+    projectdir TEXT NOT NULL,
+    command TEXT NOT NULL,
+    flags TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rummage_access_log_entry ON rummage_access_log (entry);
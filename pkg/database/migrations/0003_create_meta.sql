@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS rummage_meta (
+    key TEXT NOT NULL PRIMARY KEY,
+    value TEXT NOT NULL
+);
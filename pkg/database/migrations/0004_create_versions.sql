@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS rummage_versions (
+    entry TEXT NOT NULL,
+    version TEXT NOT NULL,
+    source TEXT NOT NULL,
+    firstseen INTEGER NOT NULL,
+    lastseen INTEGER NOT NULL,
+    PRIMARY KEY (entry, version, source)
+);
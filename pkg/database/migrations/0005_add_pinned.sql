@@ -0,0 +1 @@
+ALTER TABLE rummage_items ADD COLUMN pinned TEXT NOT NULL DEFAULT '';
@@ -4,8 +4,31 @@
 
 package database
 
+type RummageAccessLog struct {
+	ID         int64
+	Entry      string
+	Timestamp  int64
+	Projectdir string
+	Command    string
+	Flags      string
+}
+
 type RummageItem struct {
 	Entry        string
 	Score        float64
 	Lastaccessed int64
+	Pinned       string
+}
+
+type RummageMetum struct {
+	Key   string
+	Value string
+}
+
+type RummageVersion struct {
+	Entry     string
+	Version   string
+	Source    string
+	Firstseen int64
+	Lastseen  int64
 }
@@ -36,3 +36,74 @@ WHERE entry = ? ;
 
 -- name: DeleteAllItem :exec
 DELETE FROM rummage_items ;
+
+-- name: SelectAllItems :many
+SELECT * FROM rummage_items
+ORDER BY score
+DESC ;
+
+-- name: LogAccess :exec
+INSERT INTO rummage_access_log (
+    entry, timestamp, projectdir, command, flags
+) VALUES (
+    ?, ?, ?, ?, ?
+) ;
+
+-- name: SelectAccessLog :many
+SELECT * FROM rummage_access_log
+WHERE entry = ?
+ORDER BY timestamp ASC, id ASC ;
+
+-- name: SelectAllAccessLogs :many
+SELECT * FROM rummage_access_log
+ORDER BY entry ASC, timestamp ASC, id ASC ;
+
+-- name: SeedItem :exec
+INSERT INTO rummage_items (
+    entry, score, lastaccessed
+) VALUES (
+    ?, ?, ?
+)
+ON CONFLICT (entry) DO UPDATE
+SET score = MAX(score, excluded.score) ;
+
+-- name: AddItemIfMissing :execrows
+INSERT INTO rummage_items (
+    entry, score, lastaccessed
+) VALUES (
+    ?, ?, ?
+)
+ON CONFLICT (entry) DO NOTHING ;
+
+-- name: SelectMeta :one
+SELECT value FROM rummage_meta
+WHERE key = ?
+LIMIT 1 ;
+
+-- name: SetMeta :exec
+INSERT INTO rummage_meta (
+    key, value
+) VALUES (
+    ?, ?
+)
+ON CONFLICT (key) DO UPDATE
+SET value = excluded.value ;
+
+-- name: AddVersion :exec
+INSERT INTO rummage_versions (
+    entry, version, source, firstseen, lastseen
+) VALUES (
+    ?, ?, ?, ?, ?
+)
+ON CONFLICT (entry, version, source) DO UPDATE
+SET lastseen = excluded.lastseen ;
+
+-- name: SelectVersions :many
+SELECT * FROM rummage_versions
+WHERE entry = ?
+ORDER BY lastseen DESC ;
+
+-- name: PinItem :execrows
+UPDATE rummage_items
+SET pinned = ?
+WHERE entry = ? ;
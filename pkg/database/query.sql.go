@@ -15,7 +15,7 @@ INSERT INTO rummage_items (
 ) VALUES (
     ?, ?, ?
 )
-RETURNING entry, score, lastaccessed
+RETURNING entry, score, lastaccessed, pinned
 `
 
 type AddItemParams struct {
@@ -27,10 +27,71 @@ type AddItemParams struct {
 func (q *Queries) AddItem(ctx context.Context, arg AddItemParams) (RummageItem, error) {
 	row := q.db.QueryRowContext(ctx, addItem, arg.Entry, arg.Score, arg.Lastaccessed)
 	var i RummageItem
-	err := row.Scan(&i.Entry, &i.Score, &i.Lastaccessed)
+	err := row.Scan(
+		&i.Entry,
+		&i.Score,
+		&i.Lastaccessed,
+		&i.Pinned,
+	)
 	return i, err
 }
 
+const addItemIfMissing = `-- name: AddItemIfMissing :execrows
+;
+
+INSERT INTO rummage_items (
+    entry, score, lastaccessed
+) VALUES (
+    ?, ?, ?
+)
+ON CONFLICT (entry) DO NOTHING
+`
+
+type AddItemIfMissingParams struct {
+	Entry        string
+	Score        float64
+	Lastaccessed int64
+}
+
+func (q *Queries) AddItemIfMissing(ctx context.Context, arg AddItemIfMissingParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, addItemIfMissing, arg.Entry, arg.Score, arg.Lastaccessed)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const addVersion = `-- name: AddVersion :exec
+;
+
+INSERT INTO rummage_versions (
+    entry, version, source, firstseen, lastseen
+) VALUES (
+    ?, ?, ?, ?, ?
+)
+ON CONFLICT (entry, version, source) DO UPDATE
+SET lastseen = excluded.lastseen
+`
+
+type AddVersionParams struct {
+	Entry     string
+	Version   string
+	Source    string
+	Firstseen int64
+	Lastseen  int64
+}
+
+func (q *Queries) AddVersion(ctx context.Context, arg AddVersionParams) error {
+	_, err := q.db.ExecContext(ctx, addVersion,
+		arg.Entry,
+		arg.Version,
+		arg.Source,
+		arg.Firstseen,
+		arg.Lastseen,
+	)
+	return err
+}
+
 const deleteAllItem = `-- name: DeleteAllItem :exec
 ;
 
@@ -60,7 +121,7 @@ func (q *Queries) DeleteItem(ctx context.Context, entry string) (int64, error) {
 const entryWithHighestScore = `-- name: EntryWithHighestScore :one
 ;
 
-SELECT entry, score, lastaccessed FROM rummage_items
+SELECT entry, score, lastaccessed, pinned FROM rummage_items
 WHERE entry LIKE ?
 ORDER BY score
 DESC LIMIT 1
@@ -69,14 +130,19 @@ DESC LIMIT 1
 func (q *Queries) EntryWithHighestScore(ctx context.Context, entry string) (RummageItem, error) {
 	row := q.db.QueryRowContext(ctx, entryWithHighestScore, entry)
 	var i RummageItem
-	err := row.Scan(&i.Entry, &i.Score, &i.Lastaccessed)
+	err := row.Scan(
+		&i.Entry,
+		&i.Score,
+		&i.Lastaccessed,
+		&i.Pinned,
+	)
 	return i, err
 }
 
 const findTopNMatches = `-- name: FindTopNMatches :many
 ;
 
-SELECT entry, score, lastaccessed FROM rummage_items
+SELECT entry, score, lastaccessed, pinned FROM rummage_items
 WHERE entry LIKE ?
 ORDER BY score
 DESC LIMIT ?
@@ -96,7 +162,196 @@ func (q *Queries) FindTopNMatches(ctx context.Context, arg FindTopNMatchesParams
 	var items []RummageItem
 	for rows.Next() {
 		var i RummageItem
-		if err := rows.Scan(&i.Entry, &i.Score, &i.Lastaccessed); err != nil {
+		if err := rows.Scan(
+			&i.Entry,
+			&i.Score,
+			&i.Lastaccessed,
+			&i.Pinned,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const logAccess = `-- name: LogAccess :exec
+;
+
+INSERT INTO rummage_access_log (
+    entry, timestamp, projectdir, command, flags
+) VALUES (
+    ?, ?, ?, ?, ?
+)
+`
+
+type LogAccessParams struct {
+	Entry      string
+	Timestamp  int64
+	Projectdir string
+	Command    string
+	Flags      string
+}
+
+func (q *Queries) LogAccess(ctx context.Context, arg LogAccessParams) error {
+	_, err := q.db.ExecContext(ctx, logAccess,
+		arg.Entry,
+		arg.Timestamp,
+		arg.Projectdir,
+		arg.Command,
+		arg.Flags,
+	)
+	return err
+}
+
+const pinItem = `-- name: PinItem :execrows
+;
+
+UPDATE rummage_items
+SET pinned = ?
+WHERE entry = ?
+`
+
+type PinItemParams struct {
+	Pinned string
+	Entry  string
+}
+
+func (q *Queries) PinItem(ctx context.Context, arg PinItemParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, pinItem, arg.Pinned, arg.Entry)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const seedItem = `-- name: SeedItem :exec
+;
+
+INSERT INTO rummage_items (
+    entry, score, lastaccessed
+) VALUES (
+    ?, ?, ?
+)
+ON CONFLICT (entry) DO UPDATE
+SET score = MAX(score, excluded.score)
+`
+
+type SeedItemParams struct {
+	Entry        string
+	Score        float64
+	Lastaccessed int64
+}
+
+func (q *Queries) SeedItem(ctx context.Context, arg SeedItemParams) error {
+	_, err := q.db.ExecContext(ctx, seedItem, arg.Entry, arg.Score, arg.Lastaccessed)
+	return err
+}
+
+const selectAccessLog = `-- name: SelectAccessLog :many
+;
+
+SELECT id, entry, timestamp, projectdir, command, flags FROM rummage_access_log
+WHERE entry = ?
+ORDER BY timestamp ASC, id ASC
+`
+
+func (q *Queries) SelectAccessLog(ctx context.Context, entry string) ([]RummageAccessLog, error) {
+	rows, err := q.db.QueryContext(ctx, selectAccessLog, entry)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RummageAccessLog
+	for rows.Next() {
+		var i RummageAccessLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Entry,
+			&i.Timestamp,
+			&i.Projectdir,
+			&i.Command,
+			&i.Flags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectAllAccessLogs = `-- name: SelectAllAccessLogs :many
+;
+
+SELECT id, entry, timestamp, projectdir, command, flags FROM rummage_access_log
+ORDER BY entry ASC, timestamp ASC, id ASC
+`
+
+func (q *Queries) SelectAllAccessLogs(ctx context.Context) ([]RummageAccessLog, error) {
+	rows, err := q.db.QueryContext(ctx, selectAllAccessLogs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RummageAccessLog
+	for rows.Next() {
+		var i RummageAccessLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Entry,
+			&i.Timestamp,
+			&i.Projectdir,
+			&i.Command,
+			&i.Flags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectAllItems = `-- name: SelectAllItems :many
+;
+
+SELECT entry, score, lastaccessed, pinned FROM rummage_items
+ORDER BY score
+DESC
+`
+
+func (q *Queries) SelectAllItems(ctx context.Context) ([]RummageItem, error) {
+	rows, err := q.db.QueryContext(ctx, selectAllItems)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RummageItem
+	for rows.Next() {
+		var i RummageItem
+		if err := rows.Scan(
+			&i.Entry,
+			&i.Score,
+			&i.Lastaccessed,
+			&i.Pinned,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -114,7 +369,7 @@ const selectItem = `-- name: SelectItem :one
 ;
 
 
-SELECT entry, score, lastaccessed FROM rummage_items
+SELECT entry, score, lastaccessed, pinned FROM rummage_items
 WHERE entry = ?
 LIMIT 1
 `
@@ -122,17 +377,96 @@ LIMIT 1
 func (q *Queries) SelectItem(ctx context.Context, entry string) (RummageItem, error) {
 	row := q.db.QueryRowContext(ctx, selectItem, entry)
 	var i RummageItem
-	err := row.Scan(&i.Entry, &i.Score, &i.Lastaccessed)
+	err := row.Scan(
+		&i.Entry,
+		&i.Score,
+		&i.Lastaccessed,
+		&i.Pinned,
+	)
 	return i, err
 }
 
+const selectMeta = `-- name: SelectMeta :one
+;
+
+SELECT value FROM rummage_meta
+WHERE key = ?
+LIMIT 1
+`
+
+func (q *Queries) SelectMeta(ctx context.Context, key string) (string, error) {
+	row := q.db.QueryRowContext(ctx, selectMeta, key)
+	var value string
+	err := row.Scan(&value)
+	return value, err
+}
+
+const selectVersions = `-- name: SelectVersions :many
+;
+
+SELECT entry, version, source, firstseen, lastseen FROM rummage_versions
+WHERE entry = ?
+ORDER BY lastseen DESC
+`
+
+func (q *Queries) SelectVersions(ctx context.Context, entry string) ([]RummageVersion, error) {
+	rows, err := q.db.QueryContext(ctx, selectVersions, entry)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RummageVersion
+	for rows.Next() {
+		var i RummageVersion
+		if err := rows.Scan(
+			&i.Entry,
+			&i.Version,
+			&i.Source,
+			&i.Firstseen,
+			&i.Lastseen,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setMeta = `-- name: SetMeta :exec
+;
+
+INSERT INTO rummage_meta (
+    key, value
+) VALUES (
+    ?, ?
+)
+ON CONFLICT (key) DO UPDATE
+SET value = excluded.value
+`
+
+type SetMetaParams struct {
+	Key   string
+	Value string
+}
+
+func (q *Queries) SetMeta(ctx context.Context, arg SetMetaParams) error {
+	_, err := q.db.ExecContext(ctx, setMeta, arg.Key, arg.Value)
+	return err
+}
+
 const updateItem = `-- name: UpdateItem :exec
 ;
 
 UPDATE rummage_items
 SET score = ?, lastaccessed = ?
 WHERE entry = ?
-RETURNING entry, score, lastaccessed
+RETURNING entry, score, lastaccessed, pinned
 `
 
 type UpdateItemParams struct {
@@ -0,0 +1,240 @@
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Scoring is loosely based on fzf's, a matched character is always worth the same,
+// but it gets a bonus for being at the start of a path segment or word, or for
+// following the previous matched character. Gaps between matched characters are penalized.
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	// the start of the text, or right after a '/'
+	bonusSegment = 10
+	// right after a '-', '.' or '_'
+	bonusBoundary = 8
+	// an uppercase character after a lowercase one
+	bonusCamel = 7
+	// following the previous matched character
+	bonusConsecutive = 4
+	// the bonus of the first matched character is multiplied by this
+	bonusFirstCharMultiplier = 2
+)
+
+const negInf = -(1 << 30)
+
+// Scores how well pattern matches text, ignoring case.
+//
+// A pattern matches if all of it's characters appear in text in the same order, but not necessarily next to each other,
+// so "btea" and "bubletea" both match "github.com/charmbracelet/bubbletea".
+// The higher the score the better the match, ok is false if the pattern does not match
+func Score(pattern, text string) (score int, ok bool) {
+	p := []rune(strings.ToLower(pattern))
+	original := []rune(text)
+	score, _, ok = align(p, original, 0, len(original))
+	return score, ok
+}
+
+// Finds the best alignment of p within original[from:to], returning it's score and the index
+// of the last matched character. Bonuses are still calculated using the whole text.
+func align(p, original []rune, from, to int) (score int, end int, ok bool) {
+	t := []rune(strings.ToLower(string(original)))
+	n, m := len(p), len(t)
+	if n == 0 {
+		return 0, from - 1, true
+	}
+	if !isSubsequence(p, t[from:to]) {
+		return 0, 0, false
+	}
+
+	bonus := make([]int, m)
+	for j := from; j < to; j++ {
+		bonus[j] = bonusAt(original, j)
+	}
+
+	// prev[j] is the best score of matching the pattern so far, where the last matched character is at text[j].
+	// run[j] is the bonus of the character that started the consecutive run ending at text[j]
+	prev := make([]int, m)
+	prevRun := make([]int, m)
+	curr := make([]int, m)
+	currRun := make([]int, m)
+
+	for j := range t {
+		prev[j] = negInf
+		if j >= from && j < to && t[j] == p[0] {
+			prev[j] = scoreMatch + bonus[j]*bonusFirstCharMultiplier
+			prevRun[j] = bonus[j]
+		}
+	}
+
+	for i := 1; i < n; i++ {
+		// best score of a match that leaves a gap before text[j]
+		gap := negInf
+		for j := range t {
+			curr[j] = negInf
+			if j-2 >= from {
+				gap = max(gap+scoreGapExtension, prev[j-2]+scoreGapStart)
+			}
+			if j < from+i || j >= to || t[j] != p[i] {
+				continue
+			}
+
+			if gap > negInf/2 {
+				curr[j] = gap + scoreMatch + bonus[j]
+				currRun[j] = bonus[j]
+			}
+			if prev[j-1] > negInf/2 {
+				run := max(prevRun[j-1], bonus[j])
+				consecutive := prev[j-1] + scoreMatch + max(run, bonusConsecutive)
+				if consecutive >= curr[j] {
+					curr[j] = consecutive
+					currRun[j] = run
+				}
+			}
+		}
+		prev, curr = curr, prev
+		prevRun, currRun = currRun, prevRun
+	}
+
+	score = negInf
+	for j, s := range prev {
+		if s > score {
+			score, end = s, j
+		}
+	}
+
+	return score, end, true
+}
+
+// Same as Score, but the score is normalized between 0 and 1, where 1 is the best score any text could get for this pattern.
+//
+// A match never has a quality of exactly 0, so it can still be told apart from no match at all
+func Quality(pattern, text string) (float64, bool) {
+	score, ok := Score(pattern, text)
+	if !ok {
+		return 0, false
+	}
+	return normalize(score, len([]rune(pattern))), true
+}
+
+// Matches multiple tokens against text, where each token has to match somewhere after the previous one,
+// so "charm bubbles" matches "github.com/charmbracelet/bubbles" but "bubbles charm" does not.
+//
+// The quality is the average quality of each token, and when there's more than one token,
+// matches where the last token is not within the last path segment of the text are penalized.
+func MatchTokens(tokens []string, text string) (float64, bool) {
+	if len(tokens) == 0 {
+		return 1, true
+	}
+
+	original := []rune(text)
+	t := []rune(strings.ToLower(text))
+	patterns := make([][]rune, len(tokens))
+	for i, token := range tokens {
+		patterns[i] = []rune(strings.ToLower(token))
+	}
+
+	// place every token as far right as possible, so each token knows how far it can go
+	// while still leaving room for the tokens after it
+	limits := make([]int, len(patterns))
+	limit := len(t)
+	for i := len(patterns) - 1; i >= 0; i-- {
+		limits[i] = limit
+		start, ok := rightmostStart(patterns[i], t, limit)
+		if !ok {
+			return 0, false
+		}
+		limit = start
+	}
+
+	// the last token would rather match within the last path segment
+	segmentStart := 0
+	for j, r := range t {
+		if r == '/' {
+			segmentStart = j + 1
+		}
+	}
+
+	var total float64
+	from := 0
+	penalty := 1.0
+	for i, p := range patterns {
+		if i == len(patterns)-1 && i > 0 {
+			score, _, ok := align(p, original, max(from, segmentStart), limits[i])
+			if ok {
+				total += normalize(score, len(p))
+				break
+			}
+			penalty = lastSegmentPenalty
+		}
+
+		score, end, ok := align(p, original, from, limits[i])
+		if !ok {
+			return 0, false
+		}
+		total += normalize(score, len(p))
+		from = end + 1
+	}
+
+	return max(total/float64(len(patterns))*penalty, 0.01), true
+}
+
+// how much a multi token match is penalized when the last token is not in the last path segment
+const lastSegmentPenalty = 0.75
+
+func normalize(score, n int) float64 {
+	if n == 0 {
+		return 1
+	}
+	best := scoreMatch + bonusSegment*bonusFirstCharMultiplier + (n-1)*(scoreMatch+bonusSegment)
+	quality := float64(score) / float64(best)
+	return min(max(quality, 0.01), 1)
+}
+
+// Gets the start of the rightmost match of p in t[:limit]
+func rightmostStart(p, t []rune, limit int) (int, bool) {
+	if len(p) == 0 {
+		return limit, true
+	}
+	i := len(p) - 1
+	for j := limit - 1; j >= 0; j-- {
+		if t[j] != p[i] {
+			continue
+		}
+		if i == 0 {
+			return j, true
+		}
+		i--
+	}
+	return 0, false
+}
+
+func isSubsequence(p, t []rune) bool {
+	i := 0
+	for j := 0; j < len(t) && i < len(p); j++ {
+		if t[j] == p[i] {
+			i++
+		}
+	}
+	return i == len(p)
+}
+
+func bonusAt(text []rune, j int) int {
+	if j == 0 {
+		return bonusSegment
+	}
+	prev, curr := text[j-1], text[j]
+	switch {
+	case prev == '/':
+		return bonusSegment
+	case prev == '-' || prev == '.' || prev == '_':
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(curr):
+		return bonusCamel
+	}
+	return 0
+}
@@ -0,0 +1,108 @@
+package fuzzy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		text    string
+		matches bool
+	}{
+		{"Exact substring", "bubbletea", "github.com/charmbracelet/bubbletea", true},
+		{"Abbreviation", "btea", "github.com/charmbracelet/bubbletea", true},
+		{"Typo with a missing character", "bubletea", "github.com/charmbracelet/bubbletea", true},
+		{"Ignores case", "burntsushi", "github.com/BurntSushi/toml", true},
+		{"Empty pattern", "", "github.com/gorilla/mux", true},
+		{"Characters out of order", "xum", "github.com/gorilla/mux", false},
+		{"Pattern longer than text", "github.com/gorilla/mux/v2", "github.com/gorilla/mux", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := Score(tt.pattern, tt.text)
+			assert.Equal(t, tt.matches, ok)
+		})
+	}
+}
+
+func TestScoreRanking(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		better  string
+		worse   string
+	}{
+		{"Consecutive beats scattered", "mux", "github.com/gorilla/mux", "github.com/mattn/go-uxterm"},
+		{"Segment start beats the middle of a word", "tea", "github.com/charmbracelet/tea", "github.com/charmbracelet/steam"},
+		{"Boundary after a dash beats the middle of a word", "term", "github.com/user/go-term", "github.com/user/goterm"},
+		{"Shorter gaps beat longer gaps", "gmx", "github.com/gorilla/mux", "github.com/gorilla/mulberryx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			better, ok := Score(tt.pattern, tt.better)
+			assert.True(t, ok)
+			worse, ok := Score(tt.pattern, tt.worse)
+			assert.True(t, ok)
+			assert.Greater(t, better, worse)
+		})
+	}
+}
+
+func TestQuality(t *testing.T) {
+	best, ok := Quality("mux", "mux")
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, best)
+
+	segment, ok := Quality("mux", "github.com/gorilla/mux")
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, segment)
+
+	scattered, ok := Quality("mux", "github.com/mattn/go-uxterm")
+	assert.True(t, ok)
+	assert.Less(t, scattered, segment)
+	assert.Greater(t, scattered, 0.0)
+
+	_, ok = Quality("xum", "github.com/gorilla/mux")
+	assert.False(t, ok)
+}
+
+func TestMatchTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		tokens  []string
+		text    string
+		matches bool
+	}{
+		{"Tokens in order", []string{"charm", "bubbles"}, "github.com/charmbracelet/bubbles", true},
+		{"Tokens out of order", []string{"bubbles", "charm"}, "github.com/charmbracelet/bubbles", false},
+		{"Tokens can't share characters", []string{"mux", "mux"}, "github.com/gorilla/mux", false},
+		{"Fuzzy tokens", []string{"chrm", "bbls"}, "github.com/charmbracelet/bubbles", true},
+		{"Single token", []string{"bubbles"}, "github.com/charmbracelet/bubbles", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := MatchTokens(tt.tokens, tt.text)
+			assert.Equal(t, tt.matches, ok)
+		})
+	}
+}
+
+func TestMatchTokensQuality(t *testing.T) {
+	single, ok := MatchTokens([]string{"mux"}, "github.com/gorilla/mux")
+	assert.True(t, ok)
+	quality, _ := Quality("mux", "github.com/gorilla/mux")
+	assert.Equal(t, quality, single)
+
+	lastSegment, ok := MatchTokens([]string{"charm", "bubbles"}, "github.com/charmbracelet/bubbles")
+	assert.True(t, ok)
+	otherSegment, ok := MatchTokens([]string{"charm", "bubbles"}, "github.com/charmbracelet/bubbles/list")
+	assert.True(t, ok)
+	assert.Greater(t, lastSegment, otherSegment)
+}
@@ -0,0 +1,151 @@
+package gomod
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// A module required in a go.mod file
+type Require struct {
+	Path    string
+	Version string
+	// the requirement is marked with an "// indirect" comment
+	Indirect bool
+}
+
+// The parts of a go.mod file rummage cares about
+type ModFile struct {
+	Module   string
+	Requires []Require
+}
+
+// The parts of a go.work file rummage cares about
+type WorkFile struct {
+	// the dirs of every module in the workspace, relative to the go.work file
+	Uses []string
+}
+
+// A module listed in a go.sum file
+type Sum struct {
+	Path    string
+	Version string
+	// only the hash of the module's go.mod file is listed, which happens when the go.mod was only read
+	// to resolve versions, and none of the module's code is used
+	GoModOnly bool
+}
+
+// Parses the contents of a go.mod file, name is only used in errors
+func parseMod(name string, data []byte) (*ModFile, error) {
+	f, err := modfile.Parse(name, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	mod := &ModFile{}
+	if f.Module != nil {
+		mod.Module = f.Module.Mod.Path
+	}
+	for _, r := range f.Require {
+		mod.Requires = append(mod.Requires, Require{Path: r.Mod.Path, Version: r.Mod.Version, Indirect: r.Indirect})
+	}
+	return mod, nil
+}
+
+// Parses the contents of a go.work file, name is only used in errors
+func parseWork(name string, data []byte) (*WorkFile, error) {
+	f, err := modfile.ParseWork(name, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	work := &WorkFile{}
+	for _, u := range f.Use {
+		work.Uses = append(work.Uses, u.Path)
+	}
+	return work, nil
+}
+
+// Parses the contents of a go.mod file
+func ParseMod(data []byte) (*ModFile, error) {
+	return parseMod("go.mod", data)
+}
+
+// Parses the contents of a go.work file
+func ParseWork(data []byte) (*WorkFile, error) {
+	return parseWork("go.work", data)
+}
+
+// Parses the contents of a go.sum file, each module version is only listed once
+// even though go.sum lists a hash for both the module and it's go.mod file
+func ParseSum(data []byte) ([]Sum, error) {
+	var sums []Sum
+	seen := make(map[Sum]int)
+	for i, raw := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(raw)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: malformed go.sum entry", i+1)
+		}
+
+		version, goMod := strings.CutSuffix(fields[1], "/go.mod")
+		key := Sum{Path: fields[0], Version: version}
+		if j, ok := seen[key]; ok {
+			sums[j].GoModOnly = sums[j].GoModOnly && goMod
+			continue
+		}
+		seen[key] = len(sums)
+		sums = append(sums, Sum{Path: fields[0], Version: version, GoModOnly: goMod})
+	}
+
+	return sums, nil
+}
+
+// Reads and parses a go.mod file
+func ReadMod(path string) (*ModFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseMod(path, data)
+}
+
+// Reads and parses a go.work file
+func ReadWork(path string) (*WorkFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseWork(path, data)
+}
+
+// Reads and parses a go.sum file
+func ReadSum(path string) ([]Sum, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sums, err := ParseSum(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return sums, nil
+}
+
+// Gets the dirs of every module in a workspace, resolved against the dir the go.work file is in
+func (w *WorkFile) ModuleDirs(workDir string) []string {
+	dirs := make([]string, len(w.Uses))
+	for i, use := range w.Uses {
+		if filepath.IsAbs(use) {
+			dirs[i] = filepath.Clean(use)
+			continue
+		}
+		dirs[i] = filepath.Join(workDir, filepath.FromSlash(use))
+	}
+	return dirs
+}
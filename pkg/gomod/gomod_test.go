@@ -0,0 +1,107 @@
+package gomod
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMod(t *testing.T) {
+	data := []byte(`module example.com/monorepo // the module
+
+go 1.23.0
+
+require github.com/gorilla/mux v1.8.1
+
+require (
+	"github.com/labstack/echo/v4" v4.12.0
+	github.com/spf13/cobra v1.10.1 // indirect
+
+	// a comment on it's own line
+	golang.org/x/mod v0.20.0 // indirect; for tools
+)
+
+replace github.com/gorilla/mux => "../vendored//mux"
+
+tool golang.org/x/tools/cmd/stringer
+
+godebug default=go1.21
+
+retract [v1.0.0, v1.0.5] // published by accident
+
+exclude (
+	github.com/spf13/cobra v1.0.0
+)
+`)
+
+	mod, err := ParseMod(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/monorepo", mod.Module)
+	assert.Equal(t, []Require{
+		{Path: "github.com/gorilla/mux", Version: "v1.8.1"},
+		{Path: "github.com/labstack/echo/v4", Version: "v4.12.0"},
+		{Path: "github.com/spf13/cobra", Version: "v1.10.1", Indirect: true},
+		{Path: "golang.org/x/mod", Version: "v0.20.0", Indirect: true},
+	}, mod.Requires)
+}
+
+func TestParseModErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"Unclosed block", "require (\n\tgithub.com/gorilla/mux v1.8.1\n", "go.mod:3: syntax error (unterminated block started at go.mod:1:1)"},
+		{"Malformed require", "require github.com/gorilla/mux\n", "go.mod:1: usage: require module/path v1.2.3"},
+		{"Malformed module", "module\n", "go.mod:1: usage: module module/path"},
+		{"Unterminated quote", "module \"example.com/a\n", "go.mod:1:22: unexpected newline in string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMod([]byte(tt.data))
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestParseWork(t *testing.T) {
+	data := []byte(`go 1.23.0
+
+use ./tools
+
+use (
+	./services/billing
+	./services/users // users service
+)
+`)
+
+	work, err := ParseWork(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"./tools", "./services/billing", "./services/users"}, work.Uses)
+
+	root := filepath.Join("/", "repo")
+	assert.Equal(t, []string{
+		filepath.Join(root, "tools"),
+		filepath.Join(root, "services", "billing"),
+		filepath.Join(root, "services", "users"),
+	}, work.ModuleDirs(root))
+}
+
+func TestParseSum(t *testing.T) {
+	data := []byte(`github.com/gorilla/mux v1.8.1 h1:abc=
+github.com/gorilla/mux v1.8.1/go.mod h1:def=
+github.com/spf13/cobra v1.10.1/go.mod h1:ghi=
+`)
+
+	sums, err := ParseSum(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []Sum{
+		{Path: "github.com/gorilla/mux", Version: "v1.8.1"},
+		{Path: "github.com/spf13/cobra", Version: "v1.10.1", GoModOnly: true},
+	}, sums)
+
+	_, err = ParseSum([]byte("github.com/gorilla/mux v1.8.1\n"))
+	assert.EqualError(t, err, "line 1: malformed go.sum entry")
+}
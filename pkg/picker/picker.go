@@ -0,0 +1,195 @@
+package picker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/vague2k/rummage/pkg/database"
+	"github.com/vague2k/rummage/pkg/fuzzy"
+	"github.com/vague2k/rummage/pkg/term"
+	"github.com/vague2k/rummage/utils"
+)
+
+// Returned by Pick when the user backs out without picking anything
+var ErrCancelled = errors.New("no package was picked")
+
+// the most items shown at once, the list scrolls to follow the cursor
+const maxVisible = 10
+
+type key int
+
+const (
+	keyUp key = iota
+	keyDown
+	keyEnter
+	keyCancel
+	keyBackspace
+	keyRune
+)
+
+// Lets the user interactively pick one of the items, which are expected to already be sorted from best to worst.
+//
+// The picker is drawn on out while keys are read from in, which has to be a terminal.
+// Arrow keys (or ctrl-p and ctrl-n) move the cursor, typing filters the items, enter picks and escape or ctrl-c cancels
+func Pick(in *os.File, out io.Writer, items []database.RummageItem) (database.RummageItem, error) {
+	restore, err := term.MakeRaw(in)
+	if err != nil {
+		return database.RummageItem{}, err
+	}
+	defer restore()
+
+	m := newModel(items, time.Now().Unix())
+	lines := 0
+	buf := make([]byte, 64)
+	for {
+		// move back up to redraw over the previous frame
+		if lines > 0 {
+			fmt.Fprintf(out, "\x1b[%dA", lines)
+		}
+		view := m.view()
+		fmt.Fprintf(out, "\r\x1b[J%s", view)
+		lines = strings.Count(view, "\n")
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return database.RummageItem{}, err
+		}
+
+		for _, k := range parseKeys(buf[:n]) {
+			if !m.handle(k) {
+				continue
+			}
+			fmt.Fprintf(out, "\x1b[%dA\r\x1b[J", lines)
+			if m.cancelled {
+				return database.RummageItem{}, ErrCancelled
+			}
+			return m.picked, nil
+		}
+	}
+}
+
+type keypress struct {
+	key key
+	r   rune
+}
+
+func parseKeys(b []byte) []keypress {
+	var keys []keypress
+	for len(b) > 0 {
+		switch {
+		case len(b) >= 3 && b[0] == 0x1b && (b[1] == '[' || b[1] == 'O'):
+			switch b[2] {
+			case 'A':
+				keys = append(keys, keypress{key: keyUp})
+			case 'B':
+				keys = append(keys, keypress{key: keyDown})
+			}
+			b = b[3:]
+			continue
+		case b[0] == 0x1b || b[0] == 0x03:
+			keys = append(keys, keypress{key: keyCancel})
+		case b[0] == '\r' || b[0] == '\n':
+			keys = append(keys, keypress{key: keyEnter})
+		case b[0] == 0x7f || b[0] == 0x08:
+			keys = append(keys, keypress{key: keyBackspace})
+		case b[0] == 0x10: // ctrl-p
+			keys = append(keys, keypress{key: keyUp})
+		case b[0] == 0x0e: // ctrl-n
+			keys = append(keys, keypress{key: keyDown})
+		default:
+			r, size := utf8.DecodeRune(b)
+			if unicode.IsPrint(r) {
+				keys = append(keys, keypress{key: keyRune, r: r})
+			}
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+type model struct {
+	items   []database.RummageItem
+	now     int64
+	filter  []rune
+	visible []int // indexes of the items matching the filter
+	cursor  int
+
+	picked    database.RummageItem
+	cancelled bool
+}
+
+func newModel(items []database.RummageItem, now int64) *model {
+	m := &model{items: items, now: now}
+	m.refilter()
+	return m
+}
+
+func (m *model) refilter() {
+	m.visible = m.visible[:0]
+	for i, item := range m.items {
+		if _, ok := fuzzy.Quality(string(m.filter), item.Entry); ok {
+			m.visible = append(m.visible, i)
+		}
+	}
+	m.cursor = min(m.cursor, max(len(m.visible)-1, 0))
+}
+
+// handles a key press, reporting whether the picker is done
+func (m *model) handle(k keypress) bool {
+	switch k.key {
+	case keyUp:
+		m.cursor = max(m.cursor-1, 0)
+	case keyDown:
+		m.cursor = min(m.cursor+1, max(len(m.visible)-1, 0))
+	case keyRune:
+		m.filter = append(m.filter, k.r)
+		m.refilter()
+	case keyBackspace:
+		if len(m.filter) > 0 {
+			m.filter = m.filter[:len(m.filter)-1]
+			m.refilter()
+		}
+	case keyCancel:
+		m.cancelled = true
+		return true
+	case keyEnter:
+		if len(m.visible) == 0 {
+			return false
+		}
+		m.picked = m.items[m.visible[m.cursor]]
+		return true
+	}
+	return false
+}
+
+func (m *model) view() string {
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("pick a package (%d/%d) > %s\n", len(m.visible), len(m.items), string(m.filter)))
+
+	// scroll so the cursor is always visible
+	first := max(m.cursor-maxVisible+1, 0)
+	last := min(first+maxVisible, len(m.visible))
+	for i := first; i < last; i++ {
+		item := m.items[m.visible[i]]
+		marker := " "
+		if i == m.cursor {
+			marker = ">"
+		}
+		line := fmt.Sprintf("%s %10.4f  %-14s %s", marker, item.Score, utils.TimeAgo(item.Lastaccessed, m.now), item.Entry)
+		if i == m.cursor {
+			// reverse video
+			line = "\x1b[7m" + line + "\x1b[0m"
+		}
+		s.WriteString(line + "\n")
+	}
+
+	return s.String()
+}
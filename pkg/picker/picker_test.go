@@ -0,0 +1,83 @@
+package picker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vague2k/rummage/pkg/database"
+)
+
+var items = []database.RummageItem{
+	{Entry: "github.com/gorilla/mux", Score: 5.0, Lastaccessed: 1},
+	{Entry: "github.com/user/mux", Score: 4.5, Lastaccessed: 1},
+	{Entry: "github.com/other/muxer", Score: 4.0, Lastaccessed: 1},
+}
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys([]byte("\x1b[A\x1b[Bab\x7f\r\x1b\x03\x10\x0e"))
+	expected := []keypress{
+		{key: keyUp},
+		{key: keyDown},
+		{key: keyRune, r: 'a'},
+		{key: keyRune, r: 'b'},
+		{key: keyBackspace},
+		{key: keyEnter},
+		{key: keyCancel},
+		{key: keyCancel},
+		{key: keyUp},
+		{key: keyDown},
+	}
+	assert.Equal(t, expected, keys)
+}
+
+func TestModel(t *testing.T) {
+	t.Run("Picks the first item by default", func(t *testing.T) {
+		m := newModel(items, 0)
+		assert.True(t, m.handle(keypress{key: keyEnter}))
+		assert.Equal(t, items[0], m.picked)
+	})
+
+	t.Run("Moves the cursor without going out of bounds", func(t *testing.T) {
+		m := newModel(items, 0)
+		for range 5 {
+			m.handle(keypress{key: keyDown})
+		}
+		m.handle(keypress{key: keyUp})
+		assert.True(t, m.handle(keypress{key: keyEnter}))
+		assert.Equal(t, items[1], m.picked)
+	})
+
+	t.Run("Filters items", func(t *testing.T) {
+		m := newModel(items, 0)
+		for _, r := range "user" {
+			m.handle(keypress{key: keyRune, r: r})
+		}
+		assert.Equal(t, []int{1}, m.visible)
+		assert.Contains(t, m.view(), "(1/3) > user")
+
+		for range 4 {
+			m.handle(keypress{key: keyBackspace})
+		}
+		assert.Len(t, m.visible, 3)
+	})
+
+	t.Run("Can't pick when nothing matches the filter", func(t *testing.T) {
+		m := newModel(items, 0)
+		m.handle(keypress{key: keyRune, r: 'z'})
+		assert.False(t, m.handle(keypress{key: keyEnter}))
+	})
+
+	t.Run("Cancels", func(t *testing.T) {
+		m := newModel(items, 0)
+		assert.True(t, m.handle(keypress{key: keyCancel}))
+		assert.True(t, m.cancelled)
+	})
+
+	t.Run("Previews score and last accessed", func(t *testing.T) {
+		m := newModel(items, 1+60*60*3)
+		view := m.view()
+		assert.Contains(t, view, "5.0000")
+		assert.Contains(t, view, "3h ago")
+		assert.Contains(t, view, "github.com/gorilla/mux")
+	})
+}
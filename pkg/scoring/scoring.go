@@ -0,0 +1,136 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/vague2k/rummage/pkg/database"
+)
+
+const (
+	_HOUR = 3600
+	_DAY  = _HOUR * 24
+	_WEEK = _DAY * 7
+)
+
+// The name of the scorer used when the user does not choose one.
+const DefaultName = "default"
+
+// A Scorer decides how an item's score changes whenever it's accessed,
+// and how items are ranked against each other when looking them up.
+type Scorer interface {
+	// Returns the score an item should be stored with after being accessed at the unix time "now"
+	Access(item *database.RummageItem, now int64) float64
+	// Returns the score used to rank an item at the unix time "now", this does not have to be the stored score
+	Rank(item *database.RummageItem, now int64) float64
+}
+
+// Names of every scorer that can be chosen with New.
+func Names() []string {
+	return []string{DefaultName, "frecency", "decay"}
+}
+
+// Gets a scorer by name.
+//
+// halfLife (in seconds) is only used by the "decay" scorer, if it's 0 a week is used.
+func New(name string, halfLife int64) (Scorer, error) {
+	switch strings.ToLower(name) {
+	case "", DefaultName:
+		return Buckets{}, nil
+	case "frecency":
+		return Frecency{}, nil
+	case "decay":
+		if halfLife <= 0 {
+			halfLife = _WEEK
+		}
+		return Decay{HalfLife: halfLife}, nil
+	}
+	return nil, fmt.Errorf("unknown scorer %s, valid scorers are %s", name, strings.Join(Names(), ", "))
+}
+
+// Replays an item's access history through a scorer, and returns the score and
+// last accessed time the item ends up with.
+//
+// The timestamps are expected to be sorted from oldest to newest.
+// An item starts with a score of 1.0 at the time of it's first access, the same as an item that was just "go get"ed
+func Replay(s Scorer, timestamps []int64) (float64, int64) {
+	if len(timestamps) == 0 {
+		return 0, 0
+	}
+
+	item := database.RummageItem{Score: 1.0, Lastaccessed: timestamps[0]}
+	for _, ts := range timestamps {
+		item.Score = s.Access(&item, ts)
+		item.Lastaccessed = ts
+	}
+
+	return item.Score, item.Lastaccessed
+}
+
+// Buckets is rummage's original scorer.
+//
+// Accessing an item within the hour or day adds to it's score, but accessing an item
+// that has not been used in a week or more cuts it's score down. Items are ranked by their stored score.
+type Buckets struct{}
+
+func (Buckets) Access(item *database.RummageItem, now int64) float64 {
+	var score float64
+
+	duration := now - item.Lastaccessed
+
+	// the older the time, the lower the score
+	if duration < _HOUR {
+		score = item.Score + 4.0
+	} else if duration < _DAY {
+		score = item.Score + 2.0
+	} else if duration < _WEEK {
+		score = item.Score * 0.5
+	} else {
+		score = item.Score * 0.25
+	}
+
+	return score
+}
+
+func (Buckets) Rank(item *database.RummageItem, now int64) float64 {
+	return item.Score
+}
+
+// Frecency scores items the same way zoxide does.
+//
+// Every access adds 1 to the score, and when ranking, the score is weighted by how recently the item was accessed.
+type Frecency struct{}
+
+func (Frecency) Access(item *database.RummageItem, now int64) float64 {
+	return item.Score + 1.0
+}
+
+func (Frecency) Rank(item *database.RummageItem, now int64) float64 {
+	duration := now - item.Lastaccessed
+
+	if duration < _HOUR {
+		return item.Score * 4.0
+	} else if duration < _DAY {
+		return item.Score * 2.0
+	} else if duration < _WEEK {
+		return item.Score * 0.5
+	}
+	return item.Score * 0.25
+}
+
+// Decay halves an item's score every HalfLife seconds since it was last accessed.
+//
+// Every access adds 1 to the decayed score, so items used often stay on top while stale items sink smoothly.
+type Decay struct {
+	HalfLife int64
+}
+
+func (d Decay) Access(item *database.RummageItem, now int64) float64 {
+	return d.Rank(item, now) + 1.0
+}
+
+func (d Decay) Rank(item *database.RummageItem, now int64) float64 {
+	duration := max(now-item.Lastaccessed, 0)
+	return item.Score * math.Exp2(-float64(duration)/float64(d.HalfLife))
+}
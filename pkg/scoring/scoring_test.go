@@ -0,0 +1,133 @@
+package scoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vague2k/rummage/pkg/database"
+)
+
+const (
+	MINUTE = 60
+	HOUR   = MINUTE * 60
+	DAY    = HOUR * 24
+	WEEK   = DAY * 7
+)
+
+func TestBucketsAccess(t *testing.T) {
+	tests := []struct {
+		name          string
+		offset        int64
+		expectedScore float64
+	}{
+		{"Within the hour", MINUTE, 5.0},
+		{"Within the day", HOUR, 3.0},
+		{"Within the week", DAY, 0.5},
+		{"Past a week", WEEK + WEEK, 0.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now().Unix()
+			updated := &database.RummageItem{
+				Entry:        "calculate",
+				Score:        1.0,
+				Lastaccessed: now - tt.offset,
+			}
+			assert.Equal(t, tt.expectedScore, Buckets{}.Access(updated, now))
+		})
+	}
+}
+
+func TestFrecency(t *testing.T) {
+	tests := []struct {
+		name         string
+		offset       int64
+		expectedRank float64
+	}{
+		{"Within the hour", MINUTE, 8.0},
+		{"Within the day", HOUR, 4.0},
+		{"Within the week", DAY, 1.0},
+		{"Past a week", WEEK + WEEK, 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now().Unix()
+			item := &database.RummageItem{
+				Entry:        "calculate",
+				Score:        2.0,
+				Lastaccessed: now - tt.offset,
+			}
+			assert.Equal(t, tt.expectedRank, Frecency{}.Rank(item, now))
+			assert.Equal(t, 3.0, Frecency{}.Access(item, now))
+		})
+	}
+}
+
+func TestDecay(t *testing.T) {
+	tests := []struct {
+		name          string
+		offset        int64
+		expectedRank  float64
+		expectedScore float64
+	}{
+		{"Just accessed", 0, 4.0, 5.0},
+		{"One half life", WEEK, 2.0, 3.0},
+		{"Two half lives", WEEK + WEEK, 1.0, 2.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now().Unix()
+			item := &database.RummageItem{
+				Entry:        "calculate",
+				Score:        4.0,
+				Lastaccessed: now - tt.offset,
+			}
+			scorer := Decay{HalfLife: WEEK}
+			assert.Equal(t, tt.expectedRank, scorer.Rank(item, now))
+			assert.Equal(t, tt.expectedScore, scorer.Access(item, now))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range Names() {
+		s, err := New(name, 0)
+		assert.NoError(t, err)
+		assert.NotNil(t, s)
+	}
+
+	s, err := New("", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, Buckets{}, s)
+
+	s, err = New("decay", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, Decay{HalfLife: WEEK}, s)
+
+	_, err = New("doesnotexist", 0)
+	assert.EqualError(t, err, "unknown scorer doesnotexist, valid scorers are default, frecency, decay")
+}
+
+func TestReplay(t *testing.T) {
+	now := time.Now().Unix()
+
+	score, last := Replay(Buckets{}, nil)
+	assert.Equal(t, 0.0, score)
+	assert.Equal(t, int64(0), last)
+
+	score, last = Replay(Buckets{}, []int64{now})
+	assert.Equal(t, 5.0, score)
+	assert.Equal(t, now, last)
+
+	// 1.0 -> 5.0 (first access) -> 7.0 (within the day) -> 1.75 (past a week)
+	score, last = Replay(Buckets{}, []int64{now - WEEK*3, now - WEEK*3 + HOUR, now})
+	assert.Equal(t, 1.75, score)
+	assert.Equal(t, now, last)
+
+	score, _ = Replay(Frecency{}, []int64{now - DAY, now - HOUR, now})
+	assert.Equal(t, 4.0, score)
+}
@@ -0,0 +1,6 @@
+package term
+
+import "errors"
+
+// Returned by MakeRaw on platforms where the terminal can't be put in raw mode
+var ErrRawUnsupported = errors.New("raw terminal mode is not supported on this platform")
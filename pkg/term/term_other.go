@@ -0,0 +1,24 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package term
+
+import "os"
+
+// Reports whether f is a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Gets the width of the terminal f in columns, this is not supported on this platform
+func Width(f *os.File) (int, bool) {
+	return 0, false
+}
+
+// Puts the terminal in raw mode, this is not supported on this platform so ErrRawUnsupported is always returned
+func MakeRaw(f *os.File) (func(), error) {
+	return nil, ErrRawUnsupported
+}
@@ -0,0 +1,23 @@
+package toolchain
+
+import (
+	"context"
+	"os/exec"
+)
+
+// A GoRunner runs the go command on rummage's behalf, so the toolchain can be swapped out (e.g. with a fake in tests).
+type GoRunner interface {
+	// Runs "go" with args inside of dir, or the working directory if dir is empty.
+	// The combined stdout and stderr of the command is returned, even when the command fails
+	Run(ctx context.Context, dir string, args ...string) (string, error)
+}
+
+// Exec runs the go binary found in $PATH.
+type Exec struct{}
+
+func (Exec) Run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	b, err := cmd.CombinedOutput()
+	return string(b), err
+}
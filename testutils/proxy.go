@@ -0,0 +1,127 @@
+package testutils
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// A single version of a module served by MockGoProxy
+type ProxyModule struct {
+	Path    string
+	Version string
+	// the module's files besides go.mod, keyed by their slash separated path inside the module.
+	// When nil, the module gets a single go file so it's root is importable as a package
+	Files map[string]string
+}
+
+// Escapes a module path or version the way the module proxy protocol does,
+// every uppercase letter is replaced with "!" followed by the lowercase letter
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Builds a module proxy directory with every module version given, and points the go command at it
+// through GOPROXY=file://... so "go get" can be tested offline.
+//
+// The rest of the go environment is isolated for the test too, so the real module cache,
+// checksum database and any user settings in "go env" are never touched
+func MockGoProxy(t *testing.T, modules ...ProxyModule) string {
+	proxy := t.TempDir()
+
+	var paths []string
+	versions := make(map[string][]ProxyModule)
+	for _, m := range modules {
+		if _, ok := versions[m.Path]; !ok {
+			paths = append(paths, m.Path)
+		}
+		versions[m.Path] = append(versions[m.Path], m)
+	}
+
+	for _, path := range paths {
+		dir := filepath.Join(proxy, filepath.FromSlash(escape(path)), "@v")
+		goMod := fmt.Sprintf("module %s\n\ngo 1.21\n", path)
+		var vs []string
+		for _, m := range versions[path] {
+			vs = append(vs, m.Version)
+		}
+		writeVersionDir(t, dir, goMod, vs...)
+
+		for _, m := range versions[path] {
+			files := m.Files
+			if files == nil {
+				pkg := path[strings.LastIndex(path, "/")+1:]
+				pkg = strings.NewReplacer(".", "", "-", "").Replace(pkg)
+				files = map[string]string{pkg + ".go": "package " + pkg + "\n"}
+			}
+			mockModuleZip(t, filepath.Join(dir, escape(m.Version)+".zip"), path+"@"+m.Version, goMod, files)
+		}
+	}
+
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxy))
+	t.Setenv("GOFLAGS", "-mod=mod -modcacherw")
+	t.Setenv("GOMODCACHE", t.TempDir())
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GONOSUMDB", "")
+	t.Setenv("GOPRIVATE", "")
+	t.Setenv("GONOPROXY", "")
+	t.Setenv("GOTOOLCHAIN", "local")
+	t.Setenv("GOWORK", "off")
+	t.Setenv("GOENV", "off")
+	return proxy
+}
+
+// Writes a module zip, where every file has to be under the "module@version/" prefix
+func mockModuleZip(t *testing.T, name, prefix, goMod string, files map[string]string) {
+	f, err := os.Create(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	write := func(path, content string) {
+		zf, err := w.Create(prefix + "/" + path)
+		require.NoError(t, err)
+		_, err = zf.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	write("go.mod", goMod)
+	for path, content := range files {
+		write(path, content)
+	}
+	require.NoError(t, w.Close())
+}
+
+// Creates a throwaway module in a temp dir and changes the working directory to it for the rest of the test,
+// so whatever "go get" does to it's go.mod never reaches the repo's own go.mod.
+//
+// Use this with MockGoProxy to test the full "get" flow
+func MockGoModule(t *testing.T, path string) string {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(fmt.Sprintf("module %s\n\ngo 1.21\n", path)), 0o644)
+	require.NoError(t, err)
+	Chdir(t, dir)
+	return dir
+}
+
+// Changes the working directory to dir, the previous one is restored when the test completes
+func Chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() {
+		require.NoError(t, os.Chdir(wd))
+	})
+}
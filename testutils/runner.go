@@ -0,0 +1,94 @@
+package testutils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// A single call made to a FakeRunner
+type RunnerCall struct {
+	Dir  string
+	Args []string
+}
+
+// A toolchain.GoRunner that records every call instead of running the go command.
+//
+// By default "go get" reports adding every package it's given at v1.0.0 (or the version asked for),
+// like the real "go get" does when none of them were required before, and "go env GOWORK" finds the go.work
+// file the way the real go command does. Set Respond to change that
+type FakeRunner struct {
+	mu    sync.Mutex
+	Calls []RunnerCall
+	// when set, it's called instead of the default behavior
+	Respond func(dir string, args ...string) (string, error)
+}
+
+func (f *FakeRunner) Run(ctx context.Context, dir string, args ...string) (string, error) {
+	f.mu.Lock()
+	f.Calls = append(f.Calls, RunnerCall{Dir: dir, Args: args})
+	f.mu.Unlock()
+
+	if f.Respond != nil {
+		return f.Respond(dir, args...)
+	}
+	if len(args) == 2 && args[0] == "env" && args[1] == "GOWORK" {
+		return goWork(dir), nil
+	}
+	if len(args) == 0 || args[0] != "get" {
+		return "", nil
+	}
+
+	var out strings.Builder
+	for _, arg := range args[1:] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		pkg, version, _ := strings.Cut(arg, "@")
+		switch version {
+		case "none":
+			out.WriteString(fmt.Sprintf("go: removed %s v1.0.0\n", pkg))
+			continue
+		case "", "latest", "upgrade", "patch":
+			version = "v1.0.0"
+		}
+		out.WriteString(fmt.Sprintf("go: added %s %s\n", pkg, version))
+	}
+	return out.String(), nil
+}
+
+// Gets the go.work file used inside of dir, which is $GOWORK when it's set,
+// or the first go.work found in dir or any of it's parents
+func goWork(dir string) string {
+	if env, ok := os.LookupEnv("GOWORK"); ok && env != "" {
+		return env + "\n"
+	}
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	for {
+		work := filepath.Join(dir, "go.work")
+		if info, err := os.Stat(work); err == nil && !info.IsDir() {
+			return work + "\n"
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "\n"
+		}
+		dir = parent
+	}
+}
+
+// Gets the args of every "go" call made so far, e.g. "get -u github.com/gorilla/mux"
+func (f *FakeRunner) Commands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	commands := make([]string, len(f.Calls))
+	for i, call := range f.Calls {
+		commands[i] = strings.Join(call.Args, " ")
+	}
+	return commands
+}
@@ -5,12 +5,12 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
-	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vague2k/rummage/pkg/database"
 )
 
@@ -19,7 +19,7 @@ import (
 // This function already includes a cleanup function where when the test completes, the database is closed
 func InMemDb(t *testing.T) (*database.Queries, context.Context) {
 	db, err := database.Init(":memory:")
-	assert.NoError(t, err)
+	require.NoError(t, err)
 	t.Cleanup(func() {
 		db = nil
 	})
@@ -34,6 +34,8 @@ func Execute(cmd *cobra.Command, args ...string) string {
 
 	cmd.SetOut(buf)
 	cmd.SetErr(buf)
+	// stdin is never a terminal, so nothing interactive can block a test
+	cmd.SetIn(new(bytes.Buffer))
 	cmd.SetArgs(args)
 	if err := cmd.Execute(); err != nil {
 		panic(err)
@@ -42,63 +44,180 @@ func Execute(cmd *cobra.Command, args ...string) string {
 	return buf.String()
 }
 
-// Mock the $GOPATH/pkg/mod/github.com dir using the test's TempDir
+// Writes every file to dir, keyed by it's path relative to dir. Any missing parent dirs are created
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		require.NoError(t, err)
+		err = os.WriteFile(path, []byte(content), 0o644)
+		require.NoError(t, err)
+	}
+}
+
+// Writes a module's "@v" dir, which is laid out the same in the module cache's download index and in a module proxy.
+// It holds a "list" of every version, and a ".info" and ".mod" file for each version
+func writeVersionDir(t *testing.T, dir string, goMod string, versions ...string) {
+	files := map[string]string{"list": strings.Join(versions, "\n") + "\n"}
+	for _, v := range versions {
+		files[escape(v)+".info"] = fmt.Sprintf(`{"Version":%q,"Time":"2024-01-01T00:00:00Z"}`, v)
+		files[escape(v)+".mod"] = goMod
+	}
+	writeFiles(t, dir, files)
+}
+
+// Adds a module to a mocked module cache's download index, the module path and versions
+// are expected to already be escaped. With no versions, the module's "@v" dir is left empty
+func mockIndexedModule(t *testing.T, modCache string, escapedPath string, versions ...string) {
+	dir := filepath.Join(modCache, "cache", "download", filepath.FromSlash(escapedPath), "@v")
+	err := os.MkdirAll(dir, os.ModePerm)
+	require.NoError(t, err)
+
+	if len(versions) > 0 {
+		writeVersionDir(t, dir, "module "+escapedPath+"\n", versions...)
+	}
+}
+
+// Mock the $GOPATH/pkg/mod dir using the test's TempDir
 //
-// Only 3 out of 3 dirs should be "valid"
+// Only 3 out of 3 modules in the download index should be "valid"
 func Mock3outof3pkgs(t *testing.T) string {
-	dir := filepath.Join(t.TempDir(), "go", "pkg", "mod", "github.com")
+	dir := filepath.Join(t.TempDir(), "go", "pkg", "mod")
 	for i := range 3 {
-		parentDir := filepath.Join(dir, fmt.Sprintf("dir%d", i))
-		err := os.MkdirAll(parentDir, os.ModePerm)
-		assert.NoError(t, err)
-
-		childDir := filepath.Join(parentDir, fmt.Sprintf("child@%d.0.0", i))
-		err = os.MkdirAll(childDir, os.ModePerm)
-		assert.NoError(t, err)
+		mockIndexedModule(t, dir, fmt.Sprintf("github.com/dir%d/child", i), fmt.Sprintf("v%d.0.0", i))
 	}
 
 	return dir
 }
 
-// Mock the $GOPATH/pkg/mod/github.com dir using the test's TempDir
+// Mock the $GOPATH/pkg/mod dir using the test's TempDir
 //
-// Only 1 out of 3 dirs should be "valid"
+// Only 1 out of 3 modules in the download index should be "valid", the others have no known versions
 func Mock1outof3pkgs(t *testing.T) string {
-	dir := filepath.Join(t.TempDir(), "go", "pkg", "mod", "github.com")
+	dir := filepath.Join(t.TempDir(), "go", "pkg", "mod")
 	for i := range 3 {
-		parentDir := filepath.Join(dir, fmt.Sprintf("dir%d", i))
-		if err := os.MkdirAll(parentDir, os.ModePerm); err != nil {
-			t.Error(err)
-		}
-
 		if i == 0 {
-			childDir := filepath.Join(parentDir, fmt.Sprintf("child@%d.0.0", i))
-			err := os.MkdirAll(childDir, os.ModePerm)
-			assert.NoError(t, err)
-		} else {
-			childDir := filepath.Join(parentDir, fmt.Sprintf("file@%d.0.0", i))
-			file, err := os.Create(childDir)
-			assert.NoError(t, err)
-			file.Close()
+			mockIndexedModule(t, dir, fmt.Sprintf("github.com/dir%d/child", i), fmt.Sprintf("v%d.0.0", i))
+			continue
 		}
+		mockIndexedModule(t, dir, fmt.Sprintf("github.com/dir%d/child", i))
+		lock, err := os.Create(filepath.Join(dir, "cache", "download", "github.com", fmt.Sprintf("dir%d", i), "child", "@v", "v1.0.0.lock"))
+		require.NoError(t, err)
+		lock.Close()
 	}
 
 	return dir
 }
 
-// Use this for the "get" command tests.
+// Mock a whole module cache ($GOPATH/pkg/mod) using the test's TempDir
 //
-// When running these tests locally it can affect our go.mod file,
-// which would need to get cleaned up after every test
+// The download index holds 4 valid modules from different hosts, alongside the checksum database which should be skipped.
+// Extracted modules that are not in the download index should not be mistaken for modules
+func MockModCache(t *testing.T) string {
+	dir := filepath.Join(t.TempDir(), "go", "pkg", "mod")
+	mockIndexedModule(t, dir, "github.com/!burnt!sushi/toml", "v1.3.2")
+	mockIndexedModule(t, dir, "golang.org/x/mod", "v0.20.0")
+	mockIndexedModule(t, dir, "gopkg.in/yaml.v3", "v3.0.1")
+	mockIndexedModule(t, dir, "go.uber.org/zap", "v1.26.0", "v1.27.0")
+
+	dirs := []string{
+		filepath.Join("cache", "download", "sumdb", "sum.golang.org", "lookup"),
+		filepath.Join("github.com", "gorilla", "mux@v1.8.0", "internal"),
+	}
+	for _, d := range dirs {
+		err := os.MkdirAll(filepath.Join(dir, d), os.ModePerm)
+		require.NoError(t, err)
+	}
+
+	return dir
+}
+
+// Mock a monorepo using the test's TempDir
 //
-// This is not the most clever way of handling this issue, but it's a whole
-// FUCK of alot easier then... idk say spinning up a docker container with
-// a dedicated go project directory just for these tests. And it suprisingly
-// works a lot better than I thought it would
-func GoModTidy(t *testing.T) {
-	cmd := exec.Command("go", "mod", "tidy")
-	err := cmd.Run()
-	assert.NoError(t, err)
+// The go.work uses 2 modules, "api" directly requires gorilla/mux and cobra while "worker" directly requires zap,
+// and indirectly requires cobra. "api" also requires "worker", which is part of the workspace.
+// Only "worker" has a go.sum, which also lists the code of multierr and only the go.mod of yaml.v3
+func MockProject(t *testing.T) string {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.work": "go 1.23.0\n\nuse (\n\t./api\n\t./worker\n)\n",
+		filepath.Join("api", "go.mod"): `module example.com/monorepo/api
+
+go 1.23.0
+
+require (
+	example.com/monorepo/worker v0.0.0
+	github.com/gorilla/mux v1.8.1
+	github.com/spf13/cobra v1.10.1
+)
+`,
+		filepath.Join("worker", "go.mod"): `module example.com/monorepo/worker
+
+go 1.23.0
+
+require go.uber.org/zap v1.27.0
+
+require github.com/spf13/cobra v1.10.1 // indirect
+`,
+		filepath.Join("worker", "go.sum"): `go.uber.org/multierr v1.10.0 h1:ddd=
+go.uber.org/multierr v1.10.0/go.mod h1:eee=
+go.uber.org/zap v1.27.0 h1:aaa=
+go.uber.org/zap v1.27.0/go.mod h1:bbb=
+gopkg.in/yaml.v3 v3.0.1/go.mod h1:ccc=
+`,
+	})
+
+	return dir
+}
+
+// Mock a go.work workspace using the test's TempDir, where every module is empty and lives in a dir
+// of the same name (e.g. the module in "api" is "example.com/monorepo/api")
+func MockWorkspace(t *testing.T, modules ...string) string {
+	dir := t.TempDir()
+	files := map[string]string{}
+	work := "go 1.21\n\nuse (\n"
+	for _, m := range modules {
+		work += "\t./" + m + "\n"
+		files[filepath.Join(m, "go.mod")] = fmt.Sprintf("module example.com/monorepo/%s\n\ngo 1.21\n", m)
+	}
+	files["go.work"] = work + ")\n"
+	writeFiles(t, dir, files)
+
+	return dir
+}
+
+// Mock a go source tree using the test's TempDir
+//
+// "golang.org/x/exp/slices" is imported by 2 files and "github.com/gorilla/mux" by 1, every other import is either
+// from the standard library, the module itself, a vendor or testdata dir, or a file that does not parse
+func MockSourceTree(t *testing.T) string {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.23.0\n",
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/app/internal/store"
+	"github.com/gorilla/mux"
+	"golang.org/x/exp/slices"
+)
+`,
+		filepath.Join("internal", "store", "store.go"): `package store
+
+import (
+	"database/sql"
+
+	"golang.org/x/exp/slices"
+)
+`,
+		filepath.Join("vendor", "github.com", "vendored", "dep", "dep.go"): "package dep\n\nimport \"github.com/vendored/other\"\n",
+		filepath.Join("testdata", "fixture.go"):                            "package fixture\n\nimport \"github.com/testdata/fixture\"\n",
+		"broken.go":                                                        "package main\n\nimport \"github.com/broken/import\n",
+	})
+
+	return dir
 }
 
 // To be used in get_test.go, see the "NOTE" comment left in that file.
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vague2k/rummage/pkg/toolchain"
+)
+
+// The parts of the go environment rummage cares about
+type GoEnvironment struct {
+	// may hold multiple paths, see filepath.SplitList
+	GOPATH     string
+	GOMODCACHE string
+}
+
+// Gets the go environment the same way "go env" would.
+//
+// The go toolchain is asked first through runner since it knows about everything, including settings written with "go env -w".
+// If go can't be run, the environment variables, then the go env config file, then go's defaults are used instead
+func ResolveGoEnv(ctx context.Context, runner toolchain.GoRunner) GoEnvironment {
+	return resolveGoEnv(
+		func() ([]byte, error) {
+			output, err := runner.Run(ctx, "", "env", "-json", "GOPATH", "GOMODCACHE")
+			return []byte(output), err
+		},
+		os.Getenv,
+		goEnvFile(),
+	)
+}
+
+// Gets the module cache dir, this is $GOMODCACHE or "$GOPATH/pkg/mod" when it's not set
+func ModCache(ctx context.Context, runner toolchain.GoRunner) string {
+	return ResolveGoEnv(ctx, runner).GOMODCACHE
+}
+
+func resolveGoEnv(goEnvJSON func() ([]byte, error), getenv func(string) string, configFile string) GoEnvironment {
+	if b, err := goEnvJSON(); err == nil {
+		var env GoEnvironment
+		if err := json.Unmarshal(b, &env); err == nil && env.GOPATH != "" && env.GOMODCACHE != "" {
+			return env
+		}
+	}
+
+	config := readGoEnvFile(configFile)
+	lookup := func(key string) string {
+		if v := getenv(key); v != "" {
+			return v
+		}
+		return config[key]
+	}
+
+	env := GoEnvironment{
+		GOPATH:     lookup("GOPATH"),
+		GOMODCACHE: lookup("GOMODCACHE"),
+	}
+	if env.GOPATH == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			env.GOPATH = filepath.Join(home, "go")
+		}
+	}
+	if paths := filepath.SplitList(env.GOPATH); env.GOMODCACHE == "" && len(paths) > 0 {
+		// the module cache always lives in the first GOPATH entry
+		env.GOMODCACHE = filepath.Join(paths[0], "pkg", "mod")
+	}
+
+	return env
+}
+
+// Gets the path of the file "go env -w" writes to
+func goEnvFile() string {
+	if file := os.Getenv("GOENV"); file != "" {
+		return file
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go", "env")
+}
+
+// Reads a go env config file, which holds a "KEY=value" pair on each line
+func readGoEnvFile(path string) map[string]string {
+	env := make(map[string]string)
+	if path == "" || path == "off" {
+		return env
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return env
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if ok && key != "" && !strings.HasPrefix(key, "#") {
+			env[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	return env
+}
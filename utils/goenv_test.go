@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vague2k/rummage/pkg/toolchain"
+)
+
+func TestResolveGoEnv(t *testing.T) {
+	noGo := func() ([]byte, error) {
+		return nil, errors.New("go: command not found")
+	}
+	envOf := func(vars map[string]string) func(string) string {
+		return func(key string) string {
+			return vars[key]
+		}
+	}
+
+	t.Run("Asks the go toolchain first", func(t *testing.T) {
+		goEnv := func() ([]byte, error) {
+			return []byte(`{"GOMODCACHE": "/toolchain/mod", "GOPATH": "/toolchain"}`), nil
+		}
+		env := resolveGoEnv(goEnv, envOf(map[string]string{"GOPATH": "/env"}), "")
+
+		assert.Equal(t, GoEnvironment{GOPATH: "/toolchain", GOMODCACHE: "/toolchain/mod"}, env)
+	})
+
+	t.Run("Falls back to GOMODCACHE", func(t *testing.T) {
+		env := resolveGoEnv(noGo, envOf(map[string]string{"GOPATH": "/env", "GOMODCACHE": "/cache"}), "")
+
+		assert.Equal(t, GoEnvironment{GOPATH: "/env", GOMODCACHE: "/cache"}, env)
+	})
+
+	t.Run("Uses the first entry of a GOPATH list for the module cache", func(t *testing.T) {
+		gopath := filepath.Join("/first") + string(os.PathListSeparator) + filepath.Join("/second")
+		env := resolveGoEnv(noGo, envOf(map[string]string{"GOPATH": gopath}), "")
+
+		assert.Equal(t, filepath.Join("/first", "pkg", "mod"), env.GOMODCACHE)
+	})
+
+	t.Run("Reads settings written with go env -w", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "env")
+		err := os.WriteFile(file, []byte("GOPROXY=direct\nGOMODCACHE=/written/mod\n"), 0o644)
+		assert.NoError(t, err)
+
+		env := resolveGoEnv(noGo, envOf(map[string]string{"GOPATH": "/env"}), file)
+
+		assert.Equal(t, GoEnvironment{GOPATH: "/env", GOMODCACHE: "/written/mod"}, env)
+	})
+
+	t.Run("Environment variables win over the go env file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "env")
+		err := os.WriteFile(file, []byte("GOMODCACHE=/written/mod\n"), 0o644)
+		assert.NoError(t, err)
+
+		env := resolveGoEnv(noGo, envOf(map[string]string{"GOPATH": "/env", "GOMODCACHE": "/cache"}), file)
+
+		assert.Equal(t, "/cache", env.GOMODCACHE)
+	})
+
+	t.Run("Defaults to the home dir", func(t *testing.T) {
+		home, err := os.UserHomeDir()
+		assert.NoError(t, err)
+
+		env := resolveGoEnv(noGo, envOf(nil), "")
+
+		assert.Equal(t, GoEnvironment{GOPATH: filepath.Join(home, "go"), GOMODCACHE: filepath.Join(home, "go", "pkg", "mod")}, env)
+	})
+}
+
+func TestModCache(t *testing.T) {
+	assert.NotEmpty(t, ModCache(context.Background(), toolchain.Exec{}))
+}
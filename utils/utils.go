@@ -2,37 +2,9 @@ package utils
 
 import (
 	"fmt"
-	"log"
-	"os"
-	"path/filepath"
 	"regexp"
-	"time"
-
-	"github.com/vague2k/rummage/pkg/database"
-)
-
-const (
-	_HOUR = 3600
-	_DAY  = _HOUR * 24
-	_WEEK = _DAY * 7
 )
 
-// Gets the user's $GOPATH.
-//
-// Gets "user-home-dir/go" if $GOPATH does not exist.
-func UserGoPath() string {
-	if path := os.Getenv("GOPATH"); path != "" {
-		return path
-	}
-
-	home, err := os.UserHomeDir()
-	if err != nil {
-		log.Fatal("\n", err)
-	}
-
-	return filepath.Join(home, "go")
-}
-
 func ResemblesGoPackage(entry string) error {
 	regex := regexp.MustCompile(`^([a-zA-Z0-9-]+\.)+[a-zA-Z0-9-!]+(/[a-zA-Z0-9-_\.!]+)+(/[vV]\d+)?$`)
 	if !regex.MatchString(entry) {
@@ -41,22 +13,28 @@ func ResemblesGoPackage(entry string) error {
 	return nil
 }
 
-// Recalculates an item's score based on the last time it was last accessed.
-func RecalculateScore(item *database.RummageItem) float64 {
-	var score float64
-
-	duration := time.Now().Unix() - item.Lastaccessed
-
-	// the older the time, the lower the score
-	if duration < _HOUR {
-		score = item.Score + 4.0
-	} else if duration < _DAY {
-		score = item.Score + 2.0
-	} else if duration < _WEEK {
-		score = item.Score * 0.5
-	} else {
-		score = item.Score * 0.25
+// Formats how long ago a unix timestamp was compared to now in a short, human friendly way (e.g. "3h ago", "2w ago")
+//
+// A timestamp of 0 means never, since that's what items that were added but never accessed have
+func TimeAgo(timestamp, now int64) string {
+	d := now - timestamp
+	switch {
+	case timestamp == 0:
+		return "never"
+	case d < 0:
+		return "in the future"
+	case d < 60:
+		return "just now"
+	case d < 60*60:
+		return fmt.Sprintf("%dm ago", d/60)
+	case d < 60*60*24:
+		return fmt.Sprintf("%dh ago", d/(60*60))
+	case d < 60*60*24*7:
+		return fmt.Sprintf("%dd ago", d/(60*60*24))
+	case d < 60*60*24*30:
+		return fmt.Sprintf("%dw ago", d/(60*60*24*7))
+	case d < 60*60*24*365:
+		return fmt.Sprintf("%dmo ago", d/(60*60*24*30))
 	}
-
-	return score
+	return fmt.Sprintf("%dy ago", d/(60*60*24*365))
 }
@@ -2,10 +2,8 @@ package utils
 
 import (
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/vague2k/rummage/pkg/database"
 )
 
 // TODO: add test for ResemeblesGoPackage
@@ -17,26 +15,27 @@ const (
 	WEEK   = DAY * 7
 )
 
-func TestRecalculateScore(t *testing.T) {
+func TestTimeAgo(t *testing.T) {
 	tests := []struct {
-		name          string
-		offset        int64
-		expectedScore float64
+		name     string
+		offset   int64
+		expected string
 	}{
-		{"Within the hour", MINUTE, 5.0},
-		{"Within the day", HOUR, 3.0},
-		{"Within the week", DAY, 0.5},
-		{"Past a week", WEEK + WEEK, 0.25},
+		{"Just now", 30, "just now"},
+		{"Minutes", MINUTE * 5, "5m ago"},
+		{"Hours", HOUR * 3, "3h ago"},
+		{"Days", DAY * 2, "2d ago"},
+		{"Weeks", WEEK * 2, "2w ago"},
+		{"Months", DAY * 65, "2mo ago"},
+		{"Years", DAY * 800, "2y ago"},
+		{"Future", -MINUTE, "in the future"},
+		{"Never", 1_700_000_000, "never"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			updated := &database.RummageItem{
-				Entry:        "calculate",
-				Score:        1.0,
-				Lastaccessed: time.Now().Unix() - tt.offset,
-			}
-			assert.Equal(t, tt.expectedScore, RecalculateScore(updated))
+			now := int64(1_700_000_000)
+			assert.Equal(t, tt.expected, TimeAgo(now-tt.offset, now))
 		})
 	}
 }